@@ -0,0 +1,33 @@
+// Command mobilenumbers rewrites the mobile numbers of the keycloak users, stored before the numbers were
+// normalized, to E.164, so the uniqueness check finds them in one form.
+//
+//	go run ./cmd/mobilenumbers
+//
+// It reads the keycloak settings from the same .env as the app, a repeated run changes nothing.
+package main
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/config"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"go.uber.org/zap"
+	"log"
+)
+
+func main() {
+	l, err := logger.New("INFO")
+	if err != nil {
+		log.Fatal("error func main, method NewLogger by path cmd/mobilenumbers/main.go", err)
+	}
+	cfg, err := config.Load(l)
+	if err != nil {
+		log.Fatal("error func main, method Load by path cmd/mobilenumbers/main.go", err)
+	}
+	updated, err := usecases.NewIdentity(cfg, l).NormalizeMobileNumbers(context.Background())
+	if err != nil {
+		l.Fatal("error func main, method NormalizeMobileNumbers by path cmd/mobilenumbers/main.go",
+			zap.Int("updated", updated), zap.Error(err))
+	}
+	l.Info("mobile numbers normalized", zap.Int("updated", updated))
+}
@@ -37,9 +37,9 @@ func New() *App {
 		log.Fatal("error func New, method NewLogger by path internal/app/app.go", err)
 	}
 	// Database connection
-	postgresConnection, err := newPostgresConnection(cfg)
+	postgresConnection, err := NewPostgresConnection(cfg)
 	if err != nil {
-		log.Fatal("error func New, method newPostgresConnection by path internal/app/app.go", err)
+		log.Fatal("error func New, method NewPostgresConnection by path internal/app/app.go", err)
 	}
 	database := NewDatabase(loggerLevel, postgresConnection)
 	err = postgresConnection.Ping()
@@ -68,35 +68,18 @@ func New() *App {
 func (app *App) Run(ctx context.Context) {
 	var wg sync.WaitGroup
 	h := entity.NewHub()
-	msgChan := make(chan *entity.Content, 1) // msgChan - канал для передачи сообщений
+	n := newTelegramNotifier()
 	wg.Add(1)
 	go func() {
-		if err := app.StartHTTPServer(ctx, h); err != nil {
+		if err := app.StartHTTPServer(ctx, h, n); err != nil {
 			app.Logger.Fatal("error func main, method StartHTTPServer by path cmd/main.go", zap.Error(err))
 		}
 		wg.Done()
 	}()
 	go func() {
-		if err := app.StartBot(ctx, msgChan); err != nil {
+		if err := app.StartBot(ctx, n); err != nil {
 			app.Logger.Fatal("error func main, method StartBot by path cmd/main.go", zap.Error(err))
 		}
-		wg.Done()
-	}()
-	wg.Add(1)
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				wg.Done()
-				return
-			case c, ok := <-h.Broadcast:
-				if !ok {
-					h.Broadcast = nil
-					continue
-				}
-				msgChan <- c
-			}
-		}
 	}()
 	wg.Wait()
 }
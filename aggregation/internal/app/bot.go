@@ -3,10 +3,11 @@ package app
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
@@ -43,40 +44,58 @@ func printIntro(chatId int64) {
 		" чтобы перейти на главную страницу приложения")
 }
 
+// telegramNotifier - sends the notifications of the outbox through the bot
+type telegramNotifier struct {
+	mu  sync.RWMutex
+	api *tgbotapi.BotAPI
+}
+
+func newTelegramNotifier() *telegramNotifier {
+	return &telegramNotifier{}
+}
+
+func (n *telegramNotifier) setBot(api *tgbotapi.BotAPI) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.api = api
+}
+
+// Notify - sends the message, until the bot is started the notification stays in the outbox.
+// The bot api doesn't take a context, so Notify stops waiting for it when the context is done.
+func (n *telegramNotifier) Notify(ctx context.Context, c *entity.Content) error {
+	n.mu.RLock()
+	api := n.api
+	n.mu.RUnlock()
+	if api == nil {
+		return errors.New("telegram bot is not started")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.Send(tgbotapi.NewMessage(int64(c.ChatID), c.Message))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // StartBot - launches the bot
-func (app *App) StartBot(ctx context.Context, msgChan <-chan *entity.Content) error {
+func (app *App) StartBot(ctx context.Context, n *telegramNotifier) error {
 	var err error
 	// Telegram Bot
 	if bot, err = tgbotapi.NewBotAPI(app.config.TelegramBotToken); err != nil {
 		return err
 	}
+	n.setBot(bot)
 	bot.Debug = true
 	app.Logger.Info("Authorized on account:", zap.String("username", bot.Self.UserName))
 	updateConfig := tgbotapi.NewUpdate(0)
 	updateConfig.Timeout = UpdateConfigTimeout
 	updates := bot.GetUpdatesChan(updateConfig) // Получаем все обновления от пользователя
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				//wg.Done()
-				return
-			case c, ok := <-msgChan:
-				if !ok {
-					msgChan = nil
-					continue
-				}
-				fmt.Println("Message: ", c.Message)
-				msg := tgbotapi.NewMessage(int64(c.ChatID), c.Message)
-				_, err := bot.Send(msg)
-				if err != nil {
-					app.Logger.Debug("error func StartBot, method Send by path internal/app/bot.go", zap.Error(err))
-				}
-			}
-		}
-	}()
-
 	for update := range updates {
 		chatId := update.Message.Chat.ID
 		if isStartMessage(&update) {
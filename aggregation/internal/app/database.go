@@ -21,7 +21,8 @@ func NewDatabase(logger logger.Logger, psql *sql.DB) *Database {
 	}
 }
 
-func newPostgresConnection(cfg *config.Config) (*sql.DB, error) {
+// NewPostgresConnection opens the database pool from the config, it doesn't ping the server
+func NewPostgresConnection(cfg *config.Config) (*sql.DB, error) {
 	databaseURL := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host,
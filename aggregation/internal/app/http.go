@@ -5,6 +5,7 @@ import (
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/middlewares"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/storage/files"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/storage/psql"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
 	"go.uber.org/zap"
@@ -12,18 +13,30 @@ import (
 
 var prefix = "/api/v1"
 
-func (app *App) StartHTTPServer(ctx context.Context, h *entity.Hub) error {
+func (app *App) StartHTTPServer(ctx context.Context, h *entity.Hub, n usecases.Notifier) error {
 	app.fiber.Static("/static", "./static")
 	done := make(chan struct{})
 	pr := psql.NewProfileRepo(app.Logger, app.db.psql)
+	cr := psql.NewChatRepo(app.Logger, app.db.psql)
+	or := psql.NewOutboxRepo(app.Logger, app.db.psql)
+	tx := psql.NewTransactor(app.Logger, app.db.psql)
 	im := usecases.NewIdentity(app.config, app.Logger)
-	imc := usecases.NewUserUseCases(app.Logger, im)
-	puc := usecases.NewProfileUseCases(app.Logger, pr, h)
+	ouc := usecases.NewOutboxUseCases(app.Logger, or, n)
+	go ouc.Dispatch(ctx)
+	is := files.NewImageStore(app.Logger, "static/uploads/profile")
+	puc := usecases.NewProfileUseCases(app.Logger, pr, tx, is, app.config.DeckPassCooldown, ouc, h)
+	imc := usecases.NewUserUseCases(app.Logger, im, puc)
+	cuc := usecases.NewChatUseCases(app.Logger, cr, tx, puc, ouc, h)
 	imh := http.NewUserHandler(app.Logger, imc)
 	ph := http.NewProfileHandler(app.Logger, puc)
+	ch := http.NewChatHandler(app.Logger, cuc, puc)
+	sh := http.NewSocketHandler(app.Logger, puc)
+	go sh.Run(ctx)
+	oh := http.NewOutboxHandler(app.Logger, ouc)
+	ah := http.NewAdminHandler(app.Logger, puc)
 	grp := app.fiber.Group(prefix)
 	middlewares.InitFiberMiddlewares(
-		app.fiber, app.config, app.Logger, grp, imh, ph, InitPublicRoutes, InitProtectedRoutes)
+		app.fiber, app.config, app.Logger, im, grp, imh, ph, ch, sh, oh, ah, InitPublicRoutes, InitProtectedRoutes)
 	go func() {
 		if err := app.fiber.Listen(app.config.Port); err != nil {
 			app.Logger.Fatal("error func StartHTTPServer, method Listen by path internal/app/http.go", zap.Error(err))
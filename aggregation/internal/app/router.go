@@ -2,37 +2,96 @@ package app
 
 import (
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/middlewares"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
 	"github.com/gofiber/fiber/v2"
 )
 
-func InitPublicRoutes(r fiber.Router, uh *http.UserHandler, ph *http.ProfileHandler) {
+func InitPublicRoutes(r fiber.Router, ta fiber.Handler, uh *http.UserHandler, ph *http.ProfileHandler,
+	ch *http.ChatHandler, sh *http.SocketHandler) {
 	r.Post("/user/register", uh.PostRegisterHandler())
-	r.Put("/user/update", uh.UpdateUserHandler())
-	r.Delete("/user/delete", uh.DeleteUserHandler())
-
-	r.Post("/profile/add", ph.AddProfileHandler())
-	r.Get("/profile/list", ph.GetProfileListHandler())
-	r.Get("/profile/session/:id", ph.GetProfileBySessionIDHandler())
-	r.Get("/profile/detail/:id", ph.GetProfileDetailHandler())
-	r.Post("/profile/edit", ph.UpdateProfileHandler())
-	r.Post("/profile/delete", ph.DeleteProfileHandler())
-	r.Post("/profile/image/delete", ph.DeleteProfileImageHandler())
-
-	r.Post("/review/add", ph.AddReviewHandler())
-	r.Post("/review/update", ph.UpdateReviewHandler())
-	r.Post("/review/delete", ph.DeleteReviewHandler())
-	r.Get("/review/list", ph.GetReviewListHandler())
-	r.Get("/review/detail/:id", ph.GetReviewByIDHandler())
-
-	r.Post("/like/add", ph.AddLikeHandler())
-	r.Put("/like/update", ph.UpdateLikeHandler())
-	r.Post("/like/delete", ph.DeleteLikeHandler())
-
-	r.Post("/block/add", ph.AddBlockHandler())
-	r.Put("/block/update", ph.UpdateBlockHandler())
-
-	r.Post("/complaint/add", ph.AddComplaintHandler())
+	r.Post("/user/password/reset", uh.ResetPasswordHandler())
+
+	r.Post("/profile/add", ta, ph.AddProfileHandler())
+	r.Get("/profile/list", ta, ph.GetProfileListHandler())
+	r.Get("/profile/session/:id", ta, ph.GetProfileBySessionIDHandler())
+	r.Get("/profile/detail/:id", ta, ph.GetProfileDetailHandler())
+	r.Post("/profile/edit", ta, ph.UpdateProfileHandler())
+	r.Post("/profile/delete", ta, ph.DeleteProfileHandler())
+	r.Post("/profile/image/delete", ta, ph.DeleteProfileImageHandler())
+	r.Post("/profile/image/primary", ta, ph.SetPrimaryProfileImageHandler())
+
+	r.Post("/review/add", ta, ph.AddReviewHandler())
+	r.Post("/review/update", ta, ph.UpdateReviewHandler())
+	r.Post("/review/delete", ta, ph.DeleteReviewHandler())
+	r.Get("/review/list", ta, ph.GetReviewListHandler())
+	r.Get("/review/detail/:id", ta, ph.GetReviewByIDHandler())
+
+	r.Post("/like/add", ta, ph.AddLikeHandler())
+	r.Put("/like/update", ta, ph.UpdateLikeHandler())
+	r.Post("/like/delete", ta, ph.DeleteLikeHandler())
+	r.Get("/like/incoming", ta, ph.GetIncomingLikeListHandler())
+	r.Get("/like/outgoing", ta, ph.GetOutgoingLikeListHandler())
+
+	r.Get("/deck/next", ta, ph.GetDeckNextHandler())
+	r.Post("/deck/pass", ta, ph.AddPassHandler())
+
+	r.Get("/match/list", ta, ph.GetMatchListHandler())
+	r.Get("/match/detail/:id", ta, ph.GetMatchDetailHandler())
+	r.Post("/match/delete", ta, ph.DeleteMatchHandler())
+
+	r.Get("/conversation/list", ta, ch.GetConversationListHandler())
+
+	r.Post("/message/add", ta, ch.AddMessageHandler())
+	r.Get("/message/list", ta, ch.GetMessageListHandler())
+	r.Put("/message/update", ta, ch.UpdateMessageHandler())
+	r.Post("/message/delete", ta, ch.DeleteMessageHandler())
+	r.Post("/message/read", ta, ch.ReadMessageHandler())
+
+	r.Post("/block/add", ta, ph.AddBlockHandler())
+	r.Put("/block/update", ta, ph.UpdateBlockHandler())
+
+	r.Post("/complaint/add", ta, ph.AddComplaintHandler())
+
+	r.Get("/ws", ta, sh.UpgradeHandler(), sh.EventsHandler())
 }
 
-func InitProtectedRoutes(r fiber.Router, ph *http.ProfileHandler) {
+func InitProtectedRoutes(r fiber.Router, l logger.Logger, auth fiber.Handler, ta fiber.Handler,
+	uh *http.UserHandler, ph *http.ProfileHandler, oh *http.OutboxHandler, ah *http.AdminHandler) {
+	r.Put("/user/update", auth, uh.UpdateUserHandler())
+	r.Delete("/user/delete", auth, uh.DeleteUserHandler())
+	r.Get("/user/me", auth, uh.GetMeHandler())
+	r.Post("/user/password/change", auth, uh.ChangePasswordHandler())
+	r.Post("/user/email/verify", auth, uh.VerifyEmailHandler())
+	r.Post("/user/logout/all", auth, uh.LogoutAllHandler())
+	// the token is in the Authorization header, so the telegram init data comes in the initData query
+	r.Post("/user/profile/link", auth, ta, uh.LinkProfileHandler())
+
+	admin := r.Group("/admin", auth,
+		middlewares.NewRequiresAnyRealmRole([]string{enums.RealmRoleAdmin, enums.RealmRoleModerator}, l))
+	admin.Get("/user/list", uh.GetUserListHandler())
+
+	admin.Get("/profile/list", ah.GetProfileListHandler())
+	admin.Post("/profile/block", ah.BlockProfileHandler())
+	admin.Post("/profile/unblock", ah.UnblockProfileHandler())
+	admin.Post("/profile/premium", ah.UpdatePremiumProfileHandler())
+	admin.Post("/profile/restore", ah.RestoreProfileHandler())
+
+	// the routes below are for the admin role only
+	ra := middlewares.NewRequiresRealmRole(enums.RealmRoleAdmin, l)
+	admin.Get("/outbox/list", ra, oh.GetOutboxListHandler())
+	admin.Get("/outbox/detail/:id", ra, oh.GetOutboxByIDHandler())
+	admin.Post("/outbox/replay", ra, oh.ReplayOutboxHandler())
+
+	// the same mutations as in the mini app, the ownership checks are bypassed for the admin role
+	admin.Post("/profile/edit", ra, ph.UpdateProfileHandler())
+	admin.Post("/profile/delete", ra, ph.DeleteProfileHandler())
+	admin.Post("/profile/image/delete", ra, ph.DeleteProfileImageHandler())
+	admin.Post("/profile/image/primary", ra, ph.SetPrimaryProfileImageHandler())
+	admin.Post("/review/update", ra, ph.UpdateReviewHandler())
+	admin.Post("/review/delete", ra, ph.DeleteReviewHandler())
+	admin.Put("/like/update", ra, ph.UpdateLikeHandler())
+	admin.Post("/like/delete", ra, ph.DeleteLikeHandler())
+	admin.Put("/block/update", ra, ph.UpdateBlockHandler())
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
+	"time"
 )
 
 type Config struct {
@@ -26,7 +27,21 @@ type Config struct {
 	ClientId            string `envconfig:"AGGREGATION_KEYCLOAK_CLIENT_ID"`
 	ClientSecret        string `envconfig:"AGGREGATION_KEYCLOAK_CLIENT_SECRET"`
 	RealmRS256PublicKey string `envconfig:"AGGREGATION_KEYCLOAK_REALM_RS256_PUBLIC_KEY"`
-	TelegramBotToken    string `envconfig:"TELEGRAM_BOT_TOKEN"`
+	// JWKSRefreshInterval - how often the realm signing keys are re-fetched from keycloak in the background
+	JWKSRefreshInterval time.Duration `envconfig:"AGGREGATION_KEYCLOAK_JWKS_REFRESH_INTERVAL" default:"1h"`
+	// ActionsEmailLifespan - how long the links of the keycloak execute-actions emails stay valid
+	ActionsEmailLifespan time.Duration `envconfig:"AGGREGATION_KEYCLOAK_ACTIONS_EMAIL_LIFESPAN" default:"12h"`
+	// JWTTrustSignature - skip the keycloak token introspection and accept every token with a valid signature
+	JWTTrustSignature bool `envconfig:"AGGREGATION_JWT_TRUST_SIGNATURE" default:"false"`
+	// IntrospectionCacheTTL - how long an active introspection result is reused, never longer than the token exp
+	IntrospectionCacheTTL time.Duration `envconfig:"AGGREGATION_INTROSPECTION_CACHE_TTL" default:"1m"`
+	// IntrospectionCacheSize - max number of tokens kept in the introspection cache
+	IntrospectionCacheSize int    `envconfig:"AGGREGATION_INTROSPECTION_CACHE_SIZE" default:"10000"`
+	TelegramBotToken       string `envconfig:"TELEGRAM_BOT_TOKEN"`
+	// TelegramInitDataTTL - how long the signed init data of the mini app is accepted after auth_date
+	TelegramInitDataTTL time.Duration `envconfig:"TELEGRAM_INIT_DATA_TTL" default:"24h"`
+	// DeckPassCooldown - how long a passed profile stays out of the deck, 0 hides it for good
+	DeckPassCooldown time.Duration `envconfig:"AGGREGATION_DECK_PASS_COOLDOWN" default:"720h"`
 }
 
 func Load(l logger.Logger) (*Config, error) {
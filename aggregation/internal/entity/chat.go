@@ -0,0 +1,82 @@
+package entity
+
+import "time"
+
+type Conversation struct {
+	ID            uint64    `json:"id"`
+	ProfileID     uint64    `json:"profileId"`
+	ParticipantID uint64    `json:"participantId"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type Message struct {
+	ID             uint64     `json:"id"`
+	ConversationID uint64     `json:"conversationId"`
+	SenderID       uint64     `json:"senderId"`
+	ReceiverID     uint64     `json:"receiverId"`
+	Message        string     `json:"message"`
+	IsEdited       bool       `json:"isEdited"`
+	IsDeleted      bool       `json:"isDeleted"`
+	IsRead         bool       `json:"isRead"`
+	ReadAt         *time.Time `json:"readAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+type RequestAddMessage struct {
+	ReceiverID uint64 `json:"receiverId,string" validate:"required"`
+	Message    string `json:"message"`
+}
+
+type RequestUpdateMessage struct {
+	ID      uint64 `json:"id,string" validate:"required"`
+	Message string `json:"message"`
+}
+
+type RequestDeleteMessage struct {
+	ID uint64 `json:"id,string" validate:"required"`
+}
+
+type RequestReadMessage struct {
+	ConversationID uint64 `json:"conversationId,string" validate:"required"`
+	MessageID      uint64 `json:"messageId,string" validate:"required"`
+}
+
+type ResponseReadMessage struct {
+	ConversationID uint64 `json:"conversationId"`
+	UnreadCount    uint64 `json:"unreadCount"`
+}
+
+type QueryParamsMessageList struct {
+	ConversationID uint64 `json:"conversationId"`
+	Cursor         uint64 `json:"cursor"`
+	Limit          uint64 `json:"limit"`
+}
+
+type ResponseListMessage struct {
+	HasNext bool       `json:"hasNext"`
+	Cursor  uint64     `json:"cursor"`
+	Content []*Message `json:"content"`
+}
+
+type QueryParamsConversationList struct {
+	Pagination
+}
+
+type ContentListConversation struct {
+	ID          uint64                `json:"id"`
+	ProfileID   uint64                `json:"profileId"`
+	DisplayName string                `json:"displayName"`
+	IsOnline    bool                  `json:"isOnline"`
+	LastOnline  time.Time             `json:"lastOnline"`
+	Image       *ResponseImageProfile `json:"image"`
+	LastMessage *Message              `json:"lastMessage"`
+	UnreadCount uint64                `json:"unreadCount"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+}
+
+type ResponseListConversation struct {
+	*Pagination
+	Content []*ContentListConversation `json:"content"`
+}
@@ -1,5 +1,19 @@
 package entity
 
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventTypeLike           = "like"
+	EventTypeMatch          = "match"
+	EventTypeMessage        = "message"
+	EventTypeProfileBlocked = "profile-blocked"
+	EventTypePresence       = "presence"
+)
+
+// Content - a notification sent to the user through the telegram bot
 type Content struct {
 	ChatID   uint64 `json:"chat_id"`
 	Type     string `json:"type"`
@@ -7,12 +21,78 @@ type Content struct {
 	Username string `json:"username"`
 }
 
+// Event - something that happened to the profile with ProfileID, Payload is delivered to the profile's sockets as is
+type Event struct {
+	Type      string    `json:"type"`
+	ProfileID uint64    `json:"profileId"`
+	Payload   any       `json:"payload"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PresencePayload struct {
+	ProfileID  uint64    `json:"profileId"`
+	IsOnline   bool      `json:"isOnline"`
+	LastOnline time.Time `json:"lastOnline"`
+}
+
+type ProfileBlockedPayload struct {
+	ProfileID uint64 `json:"profileId"`
+}
+
+type Subscription struct {
+	ID     uint64
+	Events <-chan *Event
+}
+
+// Hub - in-memory pub/sub bus for real-time events, every subscriber receives every published event.
+// Events may be lost, notifications that must be delivered go through the notification outbox.
 type Hub struct {
-	Broadcast chan *Content
+	mu          sync.RWMutex
+	lastID      uint64
+	subscribers map[uint64]chan *Event
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		Broadcast: make(chan *Content, 5),
+		subscribers: make(map[uint64]chan *Event),
+	}
+}
+
+func (h *Hub) Subscribe(size int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	events := make(chan *Event, size)
+	h.subscribers[h.lastID] = events
+	return &Subscription{
+		ID:     h.lastID,
+		Events: events,
+	}
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if events, ok := h.subscribers[s.ID]; ok {
+		delete(h.subscribers, s.ID)
+		close(events)
+	}
+}
+
+// Publish never blocks the caller: a subscriber whose buffer is full misses the event
+func (h *Hub) Publish(e *Event) bool {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	isDelivered := true
+	for _, events := range h.subscribers {
+		select {
+		case events <- e:
+		default:
+			isDelivered = false
+		}
 	}
+	return isDelivered
 }
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+type NotificationOutbox struct {
+	ID            uint64     `json:"id"`
+	ProfileID     uint64     `json:"profileId"`
+	Type          string     `json:"type"`
+	Payload       *Content   `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      uint32     `json:"attempts"`
+	LastError     string     `json:"lastError"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	SentAt        *time.Time `json:"sentAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type QueryParamsOutboxList struct {
+	Pagination
+	Status string `json:"status"`
+}
+
+type ResponseListOutbox struct {
+	*Pagination
+	Content []*NotificationOutbox `json:"content"`
+}
+
+type RequestReplayOutbox struct {
+	ID string `json:"id"`
+}
@@ -3,9 +3,19 @@ package entity
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 )
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Pagination - the page/size mode counts the items and skips the previous pages, the cursor mode continues
+// after the item the NextCursor of the previous response points to and doesn't count anything.
+// The response of either mode has NextCursor when there are more items, so the client can switch to the cursor
+// mode after the first page.
 type Pagination struct {
 	HasNext     bool   `json:"hasNext"`
 	HasPrevious bool   `json:"hasPrevious"`
@@ -13,6 +23,35 @@ type Pagination struct {
 	Size        uint64 `json:"size"`
 	Page        uint64 `json:"page"`
 	TotalItems  uint64 `json:"totalItems"`
+	Cursor      string `json:"cursor,omitempty"`
+	NextCursor  string `json:"nextCursor,omitempty"`
+}
+
+// Cursor - the sort key of the last item of the page, only the fields of the list order are filled
+type Cursor struct {
+	Distance   float64   `json:"d,omitempty"`
+	LastOnline time.Time `json:"l"`
+	CreatedAt  time.Time `json:"c"`
+	ID         uint64    `json:"i"`
+}
+
+// EncodeCursor returns the opaque value the client sends back as the cursor query parameter
+func EncodeCursor(c *Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns ErrInvalidCursor when the value was not made by EncodeCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := Cursor{}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
 }
 
 func NewPagination(pag *Pagination) *Pagination {
@@ -23,6 +62,7 @@ func NewPagination(pag *Pagination) *Pagination {
 		Size:        pag.Size,
 		Page:        pag.Page,
 		TotalItems:  pag.TotalItems,
+		NextCursor:  pag.NextCursor,
 	}
 }
 
@@ -32,7 +72,17 @@ func ApplyPagination(sqlQuery string, page uint64, size uint64) string {
 	return sqlQuery
 }
 
-func GetTotalItems(ctx context.Context, db *sql.DB, sqlQuery string, args ...interface{}) (uint64, error) {
+// ApplyLimit fetches one item more than the size, the extra item only tells that the next page exists
+func ApplyLimit(sqlQuery string, size uint64) string {
+	return sqlQuery + fmt.Sprintf(" LIMIT %d", size+1)
+}
+
+// RowQuerier - *sql.DB or *sql.Tx, the count of a transaction sees the rows the transaction has written
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func GetTotalItems(ctx context.Context, db RowQuerier, sqlQuery string, args ...interface{}) (uint64, error) {
 	var totalItems uint64
 	err := db.QueryRowContext(ctx, sqlQuery, args...).Scan(&totalItems)
 	if err != nil {
@@ -59,3 +109,17 @@ func GetPagination(size uint64, page uint64, totalItems uint64) *Pagination {
 	})
 	return paging
 }
+
+// GetCursorPagination is the pagination of the cursor mode, the items were fetched by ApplyLimit
+func GetCursorPagination(size uint64, countItems int, nextCursor string) *Pagination {
+	hasNext := uint64(countItems) > size
+	if !hasNext {
+		nextCursor = ""
+	}
+	return NewPagination(&Pagination{
+		HasNext:     hasNext,
+		HasPrevious: true,
+		Size:        size,
+		NextCursor:  nextCursor,
+	})
+}
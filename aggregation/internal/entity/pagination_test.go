@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	lastOnline := time.Date(2024, time.October, 20, 15, 42, 7, 123456000, time.UTC)
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"search list", Cursor{Distance: 1234.5678901234567, LastOnline: lastOnline, ID: 42}},
+		{"same place", Cursor{Distance: 0, LastOnline: lastOnline, ID: 7}},
+		{"tiny distance", Cursor{Distance: 0.1 + 0.2, LastOnline: lastOnline, ID: 8}},
+		{"review list", Cursor{CreatedAt: lastOnline, ID: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := EncodeCursor(&tt.cursor)
+			got, err := DecodeCursor(s)
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q) error = %v", s, err)
+			}
+			// the distance is compared for equality in the query, so it must come back bit for bit
+			if got.Distance != tt.cursor.Distance {
+				t.Errorf("Distance = %v, want %v", got.Distance, tt.cursor.Distance)
+			}
+			if !got.LastOnline.Equal(tt.cursor.LastOnline) || !got.CreatedAt.Equal(tt.cursor.CreatedAt) {
+				t.Errorf("DecodeCursor() = %+v, want %+v", got, tt.cursor)
+			}
+			if got.ID != tt.cursor.ID {
+				t.Errorf("ID = %d, want %d", got.ID, tt.cursor.ID)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "not a cursor!"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("cursor"))},
+		{"no id", base64.RawURLEncoding.EncodeToString([]byte(`{"d":10}`))},
+		{"wrong type", base64.RawURLEncoding.EncodeToString([]byte(`{"i":"1"}`))},
+		{"padded", base64.URLEncoding.EncodeToString([]byte(`{"i":1}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.value); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want %v", tt.value, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestGetCursorPagination(t *testing.T) {
+	tests := []struct {
+		name           string
+		countItems     int
+		wantHasNext    bool
+		wantNextCursor string
+	}{
+		{"more items", 11, true, "next"},
+		{"last page", 10, false, ""},
+		{"empty page", 0, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetCursorPagination(10, tt.countItems, "next")
+			if got.HasNext != tt.wantHasNext || got.NextCursor != tt.wantNextCursor {
+				t.Errorf("GetCursorPagination() = %+v, want HasNext %v and NextCursor %q",
+					got, tt.wantHasNext, tt.wantNextCursor)
+			}
+			if !got.HasPrevious || got.Size != 10 {
+				t.Errorf("GetCursorPagination() = %+v, want HasPrevious and the size 10", got)
+			}
+		})
+	}
+}
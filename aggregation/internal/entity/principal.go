@@ -0,0 +1,7 @@
+package entity
+
+// Principal - the caller a request is made on behalf of: the owner of ProfileID or an administrator
+type Principal struct {
+	ProfileID uint64
+	IsAdmin   bool
+}
@@ -13,6 +13,7 @@ type Profile struct {
 	Location       string                    `json:"location"`
 	Height         uint8                     `json:"height"`
 	Weight         uint8                     `json:"weight"`
+	LookingFor     string                    `json:"lookingFor"`
 	Description    string                    `json:"description"`
 	IsDeleted      bool                      `json:"isDeleted"`
 	IsBlocked      bool                      `json:"isBlocked"`
@@ -29,71 +30,61 @@ type Profile struct {
 }
 
 type RequestAddProfile struct {
-	SessionID        string    `json:"sessionId"`
-	UserName         string    `json:"userName"`
-	DisplayName      string    `json:"displayName"`
-	Birthday         time.Time `json:"birthday"`
-	Gender           string    `json:"gender"`
-	SearchGender     string    `json:"searchGender"`
-	Location         string    `json:"location"`
-	Description      string    `json:"description"`
-	Height           string    `json:"height"`
-	Weight           string    `json:"weight"`
-	LookingFor       string    `json:"lookingFor"`
-	TelegramID       string    `json:"telegramId"`
-	TelegramUserName string    `json:"telegramUserName"`
-	Firstname        string    `json:"firstName"`
-	Lastname         string    `json:"lastName"`
-	LanguageCode     string    `json:"languageCode"`
-	AllowsWriteToPm  string    `json:"allowsWriteToPm"`
-	QueryID          string    `json:"queryId"`
-	ChatID           string    `json:"chatId"`
-	Latitude         string    `json:"latitude"`
-	Longitude        string    `json:"longitude"`
-	AgeFrom          string    `json:"ageFrom"`
-	AgeTo            string    `json:"ageTo"`
-	Distance         string    `json:"distance"`
-	Page             string    `json:"page"`
-	Size             string    `json:"size"`
-	Image            []byte    `json:"image"`
-}
-
+	SessionID    string    `json:"sessionId" validate:"required,maxlen=64"`
+	UserName     string    `json:"userName"`
+	DisplayName  string    `json:"displayName" validate:"required,maxlen=64"`
+	Birthday     time.Time `json:"birthday" validate:"required,minage=18,maxage=100"`
+	Gender       string    `json:"gender" validate:"required,oneof=man woman"`
+	SearchGender string    `json:"searchGender" validate:"omitempty,oneof=man woman all"`
+	Location     string    `json:"location" validate:"maxlen=255"`
+	Description  string    `json:"description" validate:"maxlen=1000"`
+	Height       uint8     `json:"height,string" validate:"omitempty,min=100,max=250"`
+	Weight       uint8     `json:"weight,string" validate:"omitempty,min=30,max=250"`
+	LookingFor   string    `json:"lookingFor" validate:"omitempty,oneof=chat dates relationship friendship business sex all"`
+	Latitude     *float64  `json:"latitude,string" validate:"required,min=-90,max=90"`
+	Longitude    *float64  `json:"longitude,string" validate:"required,min=-180,max=180"`
+	AgeFrom      uint8     `json:"ageFrom,string" validate:"omitempty,min=18,max=100"`
+	AgeTo        uint8     `json:"ageTo,string" validate:"omitempty,min=18,max=100"`
+	Distance     uint64    `json:"distance,string" validate:"omitempty,max=1000"`
+	Page         uint64    `json:"page,string"`
+	Size         uint64    `json:"size,string" validate:"omitempty,max=100"`
+	Image        []byte    `json:"image"`
+}
+
+// RequestUpdateProfile - the location is changed only when both coordinates are sent
 type RequestUpdateProfile struct {
-	ID               string    `json:"id"`
-	UserName         string    `json:"userName"`
-	DisplayName      string    `json:"displayName"`
-	Birthday         time.Time `json:"birthday"`
-	Gender           string    `json:"gender"`
-	SearchGender     string    `json:"searchGender"`
-	Location         string    `json:"location"`
-	Description      string    `json:"description"`
-	Height           string    `json:"height"`
-	Weight           string    `json:"weight"`
-	LookingFor       string    `json:"lookingFor"`
-	TelegramID       string    `json:"telegramId"`
-	TelegramUserName string    `json:"telegramUserName"`
-	Firstname        string    `json:"firstName"`
-	Lastname         string    `json:"lastName"`
-	LanguageCode     string    `json:"languageCode"`
-	AllowsWriteToPm  string    `json:"allowsWriteToPm"`
-	QueryID          string    `json:"queryId"`
-	ChatID           string    `json:"chatId"`
-	Latitude         string    `json:"latitude"`
-	Longitude        string    `json:"longitude"`
-	AgeFrom          string    `json:"ageFrom"`
-	AgeTo            string    `json:"ageTo"`
-	Distance         string    `json:"distance"`
-	Page             string    `json:"page"`
-	Size             string    `json:"size"`
-	Image            []byte    `json:"image"`
-}
-
+	ID           uint64    `json:"id,string"`
+	UserName     string    `json:"userName"`
+	DisplayName  string    `json:"displayName" validate:"required,maxlen=64"`
+	Birthday     time.Time `json:"birthday" validate:"required,minage=18,maxage=100"`
+	Gender       string    `json:"gender" validate:"required,oneof=man woman"`
+	SearchGender string    `json:"searchGender" validate:"omitempty,oneof=man woman all"`
+	Location     string    `json:"location" validate:"maxlen=255"`
+	Description  string    `json:"description" validate:"maxlen=1000"`
+	Height       uint8     `json:"height,string" validate:"omitempty,min=100,max=250"`
+	Weight       uint8     `json:"weight,string" validate:"omitempty,min=30,max=250"`
+	LookingFor   string    `json:"lookingFor" validate:"omitempty,oneof=chat dates relationship friendship business sex all"`
+	Latitude     *float64  `json:"latitude,string" validate:"min=-90,max=90"`
+	Longitude    *float64  `json:"longitude,string" validate:"min=-180,max=180"`
+	AgeFrom      uint8     `json:"ageFrom,string" validate:"omitempty,min=18,max=100"`
+	AgeTo        uint8     `json:"ageTo,string" validate:"omitempty,min=18,max=100"`
+	Distance     uint64    `json:"distance,string" validate:"omitempty,max=1000"`
+	Page         uint64    `json:"page,string"`
+	Size         uint64    `json:"size,string" validate:"omitempty,max=100"`
+	Image        []byte    `json:"image"`
+}
+
+// RequestDeleteProfile - the own profile is deleted when the id is empty
 type RequestDeleteProfile struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string"`
 }
 
 type RequestDeleteProfileImage struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string" validate:"required"`
+}
+
+type RequestPrimaryProfileImage struct {
+	ID uint64 `json:"id,string" validate:"required"`
 }
 
 type ContentListProfile struct {
@@ -128,6 +119,7 @@ type ResponseProfileDetail struct {
 	Location       string                    `json:"location"`
 	Height         uint8                     `json:"height"`
 	Weight         uint8                     `json:"weight"`
+	LookingFor     string                    `json:"lookingFor"`
 	Description    string                    `json:"description"`
 	IsDeleted      bool                      `json:"isDeleted"`
 	IsBlocked      bool                      `json:"isBlocked"`
@@ -147,35 +139,38 @@ type ResponseProfileDetail struct {
 
 type QueryParamsProfileList struct {
 	Pagination
-	SessionID    string `json:"sessionId"`
-	AgeFrom      string `json:"ageFrom"`
-	AgeTo        string `json:"ageTo"`
-	SearchGender string `json:"searchGender"`
-	LookingFor   string `json:"lookingFor"`
-	Distance     string `json:"distance"`
-	Latitude     string `json:"latitude"`
-	Longitude    string `json:"longitude"`
+	SessionID    string   `json:"sessionId"`
+	AgeFrom      uint8    `json:"ageFrom" validate:"required,min=18,max=100"`
+	AgeTo        uint8    `json:"ageTo" validate:"required,min=18,max=100"`
+	SearchGender string   `json:"searchGender" validate:"required,oneof=man woman all"`
+	LookingFor   string   `json:"lookingFor" validate:"omitempty,oneof=chat dates relationship friendship business sex all"`
+	Distance     uint64   `json:"distance" validate:"required,max=1000"`
+	HeightFrom   uint8    `json:"heightFrom" validate:"omitempty,min=100,max=250"`
+	HeightTo     uint8    `json:"heightTo" validate:"omitempty,min=100,max=250"`
+	WeightFrom   uint8    `json:"weightFrom" validate:"omitempty,min=30,max=250"`
+	WeightTo     uint8    `json:"weightTo" validate:"omitempty,min=30,max=250"`
+	Latitude     *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" validate:"min=-180,max=180"`
 }
 
 type QueryParamsGetProfileByTelegramID struct {
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
 }
 
 type QueryParamsGetProfileByUserID struct {
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
 }
 
 type QueryParamsGetProfileByID struct {
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
 }
 
 type QueryParamsGetProfileDetail struct {
-	ViewerID  string `json:"viewerId"`
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
 }
 
 type TelegramProfile struct {
@@ -212,24 +207,32 @@ type FilterProfile struct {
 	Distance     uint64 `json:"distance"`
 	Page         uint64 `json:"page"`
 	Size         uint64 `json:"size"`
+	HeightFrom   uint8  `json:"heightFrom"`
+	HeightTo     uint8  `json:"heightTo"`
+	WeightFrom   uint8  `json:"weightFrom"`
+	WeightTo     uint8  `json:"weightTo"`
 }
 
 type ImageProfile struct {
-	ID        uint64    `json:"id"`
-	ProfileID uint64    `json:"profileId"`
-	Name      string    `json:"name"`
-	Url       string    `json:"url"`
-	Size      int64     `json:"size"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	IsDeleted bool      `json:"isDeleted"`
-	IsBlocked bool      `json:"isBlocked"`
-	IsPrimary bool      `json:"isPrimary"`
-	IsPrivate bool      `json:"isPrivate"`
-}
-
+	ID           uint64    `json:"id"`
+	ProfileID    uint64    `json:"profileId"`
+	Name         string    `json:"name"`
+	Url          string    `json:"url"`
+	ThumbnailUrl string    `json:"thumbnailUrl"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	IsDeleted    bool      `json:"isDeleted"`
+	IsBlocked    bool      `json:"isBlocked"`
+	IsPrimary    bool      `json:"isPrimary"`
+	IsPrivate    bool      `json:"isPrivate"`
+}
+
+// ResponseImageProfile - the primary image of the profile, the thumbnail is the url for the images
+// uploaded before the thumbnails were made
 type ResponseImageProfile struct {
-	Url string `json:"url"`
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnailUrl,omitempty"`
 }
 
 type ResponseTelegramProfile struct {
@@ -245,6 +248,10 @@ type ResponseFilterProfile struct {
 	Distance     uint64 `json:"distance"`
 	Page         uint64 `json:"page"`
 	Size         uint64 `json:"size"`
+	HeightFrom   uint8  `json:"heightFrom"`
+	HeightTo     uint8  `json:"heightTo"`
+	WeightFrom   uint8  `json:"weightFrom"`
+	WeightTo     uint8  `json:"weightTo"`
 }
 
 type ResponseNavigatorProfile struct {
@@ -276,7 +283,7 @@ type ResponseReviewProfile struct {
 
 type QueryParamsReviewList struct {
 	Pagination
-	ProfileID string `json:"profileId"`
+	ProfileID uint64 `json:"profileId" validate:"required"`
 }
 
 type ContentReviewProfile struct {
@@ -300,20 +307,18 @@ type ResponseListReview struct {
 }
 
 type RequestAddReview struct {
-	ProfileID string `json:"profileId"`
-	Message   string `json:"message"`
-	Rating    string `json:"rating"`
+	Message string  `json:"message" validate:"maxlen=1000"`
+	Rating  float32 `json:"rating,string" validate:"min=0,max=5"`
 }
 
 type RequestUpdateReview struct {
-	ID        string `json:"id"`
-	ProfileID string `json:"profileId"`
-	Message   string `json:"message"`
-	Rating    string `json:"rating"`
+	ID      uint64  `json:"id,string" validate:"required"`
+	Message string  `json:"message" validate:"maxlen=1000"`
+	Rating  float32 `json:"rating,string" validate:"min=0,max=5"`
 }
 
 type RequestDeleteReview struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string" validate:"required"`
 }
 
 type LikeProfile struct {
@@ -326,18 +331,38 @@ type LikeProfile struct {
 }
 
 type RequestAddLike struct {
-	SessionID   string `json:"sessionId"`
-	LikedUserID string `json:"likedUserId"`
-	Message     string `json:"message"`
+	LikedUserID uint64 `json:"likedUserId,string" validate:"required"`
+	Message     string `json:"message" validate:"maxlen=300"`
 	Username    string `json:"username"`
 }
 
 type RequestUpdateLike struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string" validate:"required"`
 }
 
 type RequestDeleteLike struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string" validate:"required"`
+}
+
+// PassProfile - the viewer skipped the profile in the deck, it is hidden until the cooldown after UpdatedAt
+type PassProfile struct {
+	ID           uint64    `json:"id"`
+	ProfileID    uint64    `json:"profileId"`
+	PassedUserID uint64    `json:"passedUserId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type RequestAddPass struct {
+	PassedUserID uint64 `json:"passedUserId,string" validate:"required"`
+}
+
+type QueryParamsDeck struct {
+	Size uint64 `json:"size" validate:"omitempty,max=50"`
+}
+
+type ResponseDeck struct {
+	Content []*ContentListProfile `json:"content"`
 }
 
 type ResponseLikeProfile struct {
@@ -346,6 +371,78 @@ type ResponseLikeProfile struct {
 	UpdatedAt *time.Time `json:"updatedAt"`
 }
 
+type QueryParamsLikeList struct {
+	Pagination
+}
+
+type ContentListLike struct {
+	ID         uint64                    `json:"id"`
+	LikeID     uint64                    `json:"likeId,omitempty"`
+	IsOnline   bool                      `json:"isOnline"`
+	IsBlurred  bool                      `json:"isBlurred"`
+	LastOnline time.Time                 `json:"lastOnline"`
+	LikedAt    time.Time                 `json:"likedAt"`
+	Image      *ResponseImageProfile     `json:"image"`
+	Navigator  *ResponseNavigatorProfile `json:"navigator"`
+}
+
+type ResponseListLike struct {
+	*Pagination
+	Content []*ContentListLike `json:"content"`
+}
+
+type ResponseAddLike struct {
+	*LikeProfile
+	IsMatch bool          `json:"isMatch"`
+	Match   *MatchProfile `json:"match"`
+}
+
+type MatchProfile struct {
+	ID            uint64    `json:"id"`
+	ProfileID     uint64    `json:"profileId"`
+	MatchedUserID uint64    `json:"matchedUserId"`
+	IsDeleted     bool      `json:"isDeleted"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type QueryParamsMatchList struct {
+	Pagination
+}
+
+type ContentListMatch struct {
+	ID          uint64                `json:"id"`
+	ProfileID   uint64                `json:"profileId"`
+	DisplayName string                `json:"displayName"`
+	IsOnline    bool                  `json:"isOnline"`
+	LastOnline  time.Time             `json:"lastOnline"`
+	Image       *ResponseImageProfile `json:"image"`
+	CreatedAt   time.Time             `json:"createdAt"`
+}
+
+type ResponseListMatch struct {
+	*Pagination
+	Content []*ContentListMatch `json:"content"`
+}
+
+type ResponseMatchDetail struct {
+	ID          uint64                    `json:"id"`
+	ProfileID   uint64                    `json:"profileId"`
+	DisplayName string                    `json:"displayName"`
+	Birthday    time.Time                 `json:"birthday"`
+	Description string                    `json:"description"`
+	IsOnline    bool                      `json:"isOnline"`
+	LastOnline  time.Time                 `json:"lastOnline"`
+	Images      []*ImageProfile           `json:"images"`
+	Telegram    *ResponseTelegramProfile  `json:"telegram"`
+	Navigator   *ResponseNavigatorProfile `json:"navigator"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+}
+
+type RequestDeleteMatch struct {
+	ID uint64 `json:"id,string" validate:"required"`
+}
+
 type BlockedProfile struct {
 	ID            uint64    `json:"id"`
 	ProfileID     uint64    `json:"profileId"`
@@ -356,12 +453,11 @@ type BlockedProfile struct {
 }
 
 type RequestAddBlock struct {
-	SessionID     string `json:"sessionId"`
-	BlockedUserID string `json:"blockedUserId"`
+	BlockedUserID uint64 `json:"blockedUserId,string" validate:"required"`
 }
 
 type RequestUpdateBlock struct {
-	ID string `json:"id"`
+	ID uint64 `json:"id,string" validate:"required"`
 }
 
 type ComplaintProfile struct {
@@ -374,7 +470,46 @@ type ComplaintProfile struct {
 }
 
 type RequestAddComplaint struct {
-	SessionID       string `json:"sessionId"`
-	ComplaintUserID string `json:"complaintUserId"`
-	Reason          string `json:"reason"`
+	ComplaintUserID uint64 `json:"complaintUserId,string" validate:"required"`
+	Reason          string `json:"reason" validate:"maxlen=500"`
+}
+
+// QueryParamsAdminProfileList - nil flags don't filter
+type QueryParamsAdminProfileList struct {
+	Pagination
+	Search    string `json:"search" validate:"maxlen=255"`
+	IsBlocked *bool  `json:"isBlocked"`
+	IsDeleted *bool  `json:"isDeleted"`
+	IsPremium *bool  `json:"isPremium"`
+}
+
+type ContentAdminProfile struct {
+	ID               uint64    `json:"id"`
+	SessionID        string    `json:"sessionId"`
+	DisplayName      string    `json:"displayName"`
+	Gender           string    `json:"gender"`
+	Location         string    `json:"location"`
+	IsDeleted        bool      `json:"isDeleted"`
+	IsBlocked        bool      `json:"isBlocked"`
+	IsPremium        bool      `json:"isPremium"`
+	IsOnline         bool      `json:"isOnline"`
+	CreatedAt        time.Time `json:"createdAt"`
+	LastOnline       time.Time `json:"lastOnline"`
+	TelegramID       uint64    `json:"telegramId"`
+	TelegramUserName string    `json:"telegramUserName"`
+	ComplaintCount   uint64    `json:"complaintCount"`
+}
+
+type ResponseListAdminProfile struct {
+	*Pagination
+	Content []*ContentAdminProfile `json:"content"`
+}
+
+type RequestAdminProfile struct {
+	ID uint64 `json:"id,string" validate:"required"`
+}
+
+type RequestAdminPremiumProfile struct {
+	ID        uint64 `json:"id,string" validate:"required"`
+	IsPremium bool   `json:"isPremium"`
 }
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// TelegramUser - the user that opened the mini app, as signed by telegram in the init data
+type TelegramUser struct {
+	ID              uint64 `json:"id"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	UserName        string `json:"username"`
+	LanguageCode    string `json:"language_code"`
+	IsPremium       bool   `json:"is_premium"`
+	AllowsWriteToPm bool   `json:"allows_write_to_pm"`
+}
+
+// TelegramInitData - verified init data of the mini app
+type TelegramInitData struct {
+	QueryID    string
+	User       *TelegramUser
+	AuthDate   time.Time
+	StartParam string
+}
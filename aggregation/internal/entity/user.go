@@ -1,5 +1,7 @@
 package entity
 
+import "github.com/Nerzal/gocloak/v13"
+
 type RegisterRequest struct {
 	Username     string `json:"username"`
 	Password     string `json:"password"`
@@ -18,6 +20,15 @@ type RequestUpdateUser struct {
 	MobileNumber string  `json:"mobileNumber"`
 }
 
+type RequestResetPassword struct {
+	Email string `json:"email"`
+}
+
+type ResponseUserMe struct {
+	User    *gocloak.User `json:"user"`
+	Profile *Profile      `json:"profile"`
+}
+
 type RequestDeleteUser struct {
 	ID *string `json:"id"`
 }
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// AdminHandler - moderation of profiles, available to the admin and moderator realm roles
+type AdminHandler struct {
+	logger logger.Logger
+	uc     *usecases.ProfileUseCases
+}
+
+func NewAdminHandler(l logger.Logger, uc *usecases.ProfileUseCases) *AdminHandler {
+	return &AdminHandler{logger: l, uc: uc}
+}
+
+// GetProfileListHandler lists profiles of any status, the search param filters them
+func (h *AdminHandler) GetProfileListHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/admin/profile/list")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		params := entity.QueryParamsAdminProfileList{}
+		if err := parseQuery(ctf, &params); err != nil {
+			h.logger.Debug("error func GetProfileListHandler, method parseQuery by path"+
+				" internal/handler/admin/admin.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.SelectListByAdmin(ctx, &params)
+		if err != nil {
+			h.logger.Debug("error func GetProfileListHandler, method SelectListByAdmin by path"+
+				" internal/handler/admin/admin.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *AdminHandler) BlockProfileHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/admin/profile/block")
+		return h.updateStatus(ctf, "BlockProfileHandler", func(ctx context.Context, id uint64) (bool, error) {
+			return h.uc.UpdateIsBlocked(ctx, id, true)
+		})
+	}
+}
+
+func (h *AdminHandler) UnblockProfileHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/admin/profile/unblock")
+		return h.updateStatus(ctf, "UnblockProfileHandler", func(ctx context.Context, id uint64) (bool, error) {
+			return h.uc.UpdateIsBlocked(ctx, id, false)
+		})
+	}
+}
+
+// RestoreProfileHandler clears the deleted flag, the profiles erased on deletion are refused with a conflict
+func (h *AdminHandler) RestoreProfileHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/admin/profile/restore")
+		return h.updateStatus(ctf, "RestoreProfileHandler", h.uc.Restore)
+	}
+}
+
+func (h *AdminHandler) UpdatePremiumProfileHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/admin/profile/premium")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestAdminPremiumProfile{}
+		if err := parseBody(ctf, &req); err != nil {
+			h.logger.Debug("error func UpdatePremiumProfileHandler, method parseBody by path"+
+				" internal/handler/admin/admin.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		profileID := req.ID
+		isExist, err := h.uc.UpdateIsPremium(ctx, profileID, req.IsPremium)
+		if err != nil {
+			h.logger.Debug("error func UpdatePremiumProfileHandler, method UpdateIsPremium by path"+
+				" internal/handler/admin/admin.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return h.respondProfile(ctf, ctx, "UpdatePremiumProfileHandler", profileID, isExist)
+	}
+}
+
+// updateStatus applies the change to the profile with the id from the body
+func (h *AdminHandler) updateStatus(ctf *fiber.Ctx, funcName string,
+	update func(ctx context.Context, id uint64) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+	defer cancel()
+	req := entity.RequestAdminProfile{}
+	if err := parseBody(ctf, &req); err != nil {
+		h.logger.Debug("error func "+funcName+", method parseBody by path"+
+			" internal/handler/admin/admin.go", zap.Error(err))
+		return api.WrapError(ctf, err, http.StatusBadRequest)
+	}
+	profileID := req.ID
+	isExist, err := update(ctx, profileID)
+	if err != nil {
+		h.logger.Debug("error func "+funcName+", method update by path"+
+			" internal/handler/admin/admin.go", zap.Error(err))
+		return api.WrapError(ctf, err, http.StatusBadRequest)
+	}
+	return h.respondProfile(ctf, ctx, funcName, profileID, isExist)
+}
+
+func (h *AdminHandler) respondProfile(
+	ctf *fiber.Ctx, ctx context.Context, funcName string, profileID uint64, isExist bool) error {
+	if !isExist {
+		err := api.NewError(api.CodeProfileNotFound)
+		return api.WrapError(ctf, err, http.StatusNotFound)
+	}
+	p, err := h.uc.FindById(ctx, profileID)
+	if err != nil {
+		h.logger.Debug("error func "+funcName+", method FindById by path"+
+			" internal/handler/admin/admin.go", zap.Error(err))
+		return api.WrapError(ctf, err, http.StatusBadRequest)
+	}
+	return api.WrapOk(ctf, p)
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/validator"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"net/http"
+)
+
+// ErrorCode - the stable identifier of the error, the clients should rely on it instead of the message
+type ErrorCode string
+
+const (
+	CodeBadRequest       ErrorCode = "BAD_REQUEST"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeForbidden        ErrorCode = "FORBIDDEN"
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodeConflict         ErrorCode = "CONFLICT"
+	CodeInternalError    ErrorCode = "INTERNAL_ERROR"
+	CodeUnavailable      ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeCursorInvalid    ErrorCode = "CURSOR_INVALID"
+
+	CodeTelegramInitDataRequired ErrorCode = "TELEGRAM_INIT_DATA_REQUIRED"
+	CodeProfileNotFound          ErrorCode = "PROFILE_NOT_FOUND"
+	CodeProfileAlreadyExists     ErrorCode = "PROFILE_ALREADY_EXISTS"
+	CodeProfileAlreadyDeleted    ErrorCode = "PROFILE_ALREADY_DELETED"
+	CodeProfileBlocked           ErrorCode = "PROFILE_BLOCKED"
+	CodeProfileUnavailable       ErrorCode = "PROFILE_UNAVAILABLE"
+	CodeProfileErased            ErrorCode = "PROFILE_ERASED"
+	CodeImageAlreadyDeleted      ErrorCode = "IMAGE_ALREADY_DELETED"
+	CodeBlockNotFound            ErrorCode = "BLOCK_NOT_FOUND"
+	CodeSelfAction               ErrorCode = "SELF_ACTION"
+	CodeLikeNotFound             ErrorCode = "LIKE_NOT_FOUND"
+	CodeMatchNotFound            ErrorCode = "MATCH_NOT_FOUND"
+	CodeMatchRequired            ErrorCode = "MATCH_REQUIRED"
+	CodeReviewAlreadyDeleted     ErrorCode = "REVIEW_ALREADY_DELETED"
+	CodeConversationNotFound     ErrorCode = "CONVERSATION_NOT_FOUND"
+	CodeMessageNotFound          ErrorCode = "MESSAGE_NOT_FOUND"
+	CodeMessageInvalid           ErrorCode = "MESSAGE_INVALID"
+	CodeNotificationNotFound     ErrorCode = "NOTIFICATION_NOT_FOUND"
+	CodeIdentityAlreadyLinked    ErrorCode = "IDENTITY_ALREADY_LINKED"
+	CodeMobileNumberInvalid      ErrorCode = "MOBILE_NUMBER_INVALID"
+	CodeMobileNumberNotUnique    ErrorCode = "MOBILE_NUMBER_NOT_UNIQUE"
+	CodeEmailNotUnique           ErrorCode = "EMAIL_NOT_UNIQUE"
+	CodeEmailAlreadyVerified     ErrorCode = "EMAIL_ALREADY_VERIFIED"
+)
+
+type catalogueEntry struct {
+	status   int
+	messages map[string]string
+}
+
+var catalogue = map[ErrorCode]catalogueEntry{
+	CodeBadRequest: {http.StatusBadRequest, map[string]string{
+		"en": "Bad request",
+		"ru": "Некорректный запрос",
+	}},
+	CodeValidationFailed: {http.StatusBadRequest, map[string]string{
+		"en": "Validation failed",
+		"ru": "Ошибка валидации",
+	}},
+	CodeUnauthorized: {http.StatusUnauthorized, map[string]string{
+		"en": "Unauthorized",
+		"ru": "Требуется авторизация",
+	}},
+	CodeForbidden: {http.StatusForbidden, map[string]string{
+		"en": "Access denied",
+		"ru": "Доступ запрещен",
+	}},
+	CodeNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Not found",
+		"ru": "Не найдено",
+	}},
+	CodeConflict: {http.StatusConflict, map[string]string{
+		"en": "Conflict",
+		"ru": "Конфликт",
+	}},
+	CodeInternalError: {http.StatusInternalServerError, map[string]string{
+		"en": "Internal server error",
+		"ru": "Внутренняя ошибка сервера",
+	}},
+	CodeUnavailable: {http.StatusServiceUnavailable, map[string]string{
+		"en": "Service is temporarily unavailable, try again later",
+		"ru": "Сервис временно недоступен, попробуйте позже",
+	}},
+	CodeCursorInvalid: {http.StatusBadRequest, map[string]string{
+		"en": "Cursor is invalid, request the list from the first page",
+		"ru": "Курсор недействителен, запросите список с первой страницы",
+	}},
+	CodeTelegramInitDataRequired: {http.StatusUnauthorized, map[string]string{
+		"en": "Telegram init data not found",
+		"ru": "Данные авторизации Telegram не найдены",
+	}},
+	CodeProfileNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Profile not found",
+		"ru": "Профиль не найден",
+	}},
+	CodeProfileAlreadyExists: {http.StatusConflict, map[string]string{
+		"en": "Profile already exists",
+		"ru": "Профиль уже существует",
+	}},
+	CodeProfileAlreadyDeleted: {http.StatusNotFound, map[string]string{
+		"en": "Profile has already been deleted",
+		"ru": "Профиль уже удален",
+	}},
+	CodeProfileBlocked: {http.StatusNotFound, map[string]string{
+		"en": "Profile is blocked",
+		"ru": "Профиль заблокирован",
+	}},
+	CodeProfileUnavailable: {http.StatusNotFound, map[string]string{
+		"en": "Profile has been deleted or blocked",
+		"ru": "Профиль удален или заблокирован",
+	}},
+	CodeProfileErased: {http.StatusConflict, map[string]string{
+		"en": "Profile data has been erased on deletion and can't be restored",
+		"ru": "Данные профиля стерты при удалении, его нельзя восстановить",
+	}},
+	CodeImageAlreadyDeleted: {http.StatusNotFound, map[string]string{
+		"en": "Image has already been deleted",
+		"ru": "Изображение уже удалено",
+	}},
+	CodeBlockNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Block not found",
+		"ru": "Блокировка не найдена",
+	}},
+	CodeSelfAction: {http.StatusBadRequest, map[string]string{
+		"en": "Action cannot be applied to your own profile",
+		"ru": "Действие нельзя применить к своему профилю",
+	}},
+	CodeLikeNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Like not found",
+		"ru": "Лайк не найден",
+	}},
+	CodeMatchNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Match not found",
+		"ru": "Совпадение не найдено",
+	}},
+	CodeMatchRequired: {http.StatusForbidden, map[string]string{
+		"en": "Messaging is allowed only after a mutual like",
+		"ru": "Переписка доступна только после взаимной симпатии",
+	}},
+	CodeReviewAlreadyDeleted: {http.StatusNotFound, map[string]string{
+		"en": "Review has already been deleted",
+		"ru": "Отзыв уже удален",
+	}},
+	CodeConversationNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Conversation not found",
+		"ru": "Диалог не найден",
+	}},
+	CodeMessageNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Message not found",
+		"ru": "Сообщение не найдено",
+	}},
+	CodeMessageInvalid: {http.StatusBadRequest, map[string]string{
+		"en": "Message must not be empty or longer than 4096 characters",
+		"ru": "Сообщение не должно быть пустым или длиннее 4096 символов",
+	}},
+	CodeNotificationNotFound: {http.StatusNotFound, map[string]string{
+		"en": "Notification not found",
+		"ru": "Уведомление не найдено",
+	}},
+	CodeIdentityAlreadyLinked: {http.StatusConflict, map[string]string{
+		"en": "Identity is already linked to another account",
+		"ru": "Учетная запись уже привязана к другому аккаунту",
+	}},
+	CodeMobileNumberInvalid: {http.StatusBadRequest, map[string]string{
+		"en": "Mobile number is invalid",
+		"ru": "Некорректный номер телефона",
+	}},
+	CodeMobileNumberNotUnique: {http.StatusConflict, map[string]string{
+		"en": "Mobile number is already in use",
+		"ru": "Номер телефона уже используется",
+	}},
+	CodeEmailNotUnique: {http.StatusConflict, map[string]string{
+		"en": "Email is already in use",
+		"ru": "Email уже используется",
+	}},
+	CodeEmailAlreadyVerified: {http.StatusConflict, map[string]string{
+		"en": "Email has already been verified",
+		"ru": "Email уже подтвержден",
+	}},
+}
+
+// sentinels maps the domain errors of the use cases to their codes, the first match wins
+var sentinels = []struct {
+	err  error
+	code ErrorCode
+}{
+	{usecases.ErrForbidden, CodeForbidden},
+	{usecases.ErrProfileNotFound, CodeProfileNotFound},
+	{usecases.ErrProfileAlreadyExists, CodeProfileAlreadyExists},
+	{usecases.ErrProfileAlreadyDeleted, CodeProfileAlreadyDeleted},
+	{usecases.ErrProfileBlocked, CodeProfileBlocked},
+	{usecases.ErrProfileUnavailable, CodeProfileUnavailable},
+	{usecases.ErrProfileErased, CodeProfileErased},
+	{usecases.ErrImageAlreadyDeleted, CodeImageAlreadyDeleted},
+	{usecases.ErrBlockNotFound, CodeBlockNotFound},
+	{usecases.ErrLikeNotFound, CodeLikeNotFound},
+	{usecases.ErrMatchNotFound, CodeMatchNotFound},
+	{usecases.ErrSelfAction, CodeSelfAction},
+	{usecases.ErrMatchRequired, CodeMatchRequired},
+	{usecases.ErrMessageInvalid, CodeMessageInvalid},
+	{usecases.ErrConversationNotFound, CodeConversationNotFound},
+	{usecases.ErrMessageNotFound, CodeMessageNotFound},
+	{usecases.ErrIdentityAlreadyLinked, CodeIdentityAlreadyLinked},
+	{usecases.ErrInvalidMobileNumber, CodeMobileNumberInvalid},
+	{usecases.ErrMobileNumberNotUnique, CodeMobileNumberNotUnique},
+	{usecases.ErrEmailNotUnique, CodeEmailNotUnique},
+	{usecases.ErrEmailAlreadyVerified, CodeEmailAlreadyVerified},
+	{entity.ErrInvalidCursor, CodeCursorInvalid},
+	{usecases.ErrNotFound, CodeNotFound},
+	{usecases.ErrConflict, CodeConflict},
+}
+
+// fieldMessages are the translations of the validator.FieldError messages, %s is the rule parameter.
+// The validator itself writes them in english.
+var fieldMessages = map[string]map[string]string{
+	validator.CodeRequired:  {"ru": "обязательное поле"},
+	validator.CodeInvalid:   {"ru": "некорректное значение"},
+	validator.CodeMin:       {"ru": "должно быть не меньше %s"},
+	validator.CodeMax:       {"ru": "должно быть не больше %s"},
+	validator.CodeMaxLength: {"ru": "должно быть не длиннее %s символов"},
+	validator.CodeOneOf:     {"ru": "должно быть одним из: %s"},
+	validator.CodeMinAge:    {"ru": "возраст должен быть не меньше %s"},
+	validator.CodeMaxAge:    {"ru": "возраст должен быть не больше %s"},
+}
+
+// codeByStatus is the generic code of the errors that are not in the catalogue
+func codeByStatus(status int) ErrorCode {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status >= http.StatusInternalServerError:
+		return CodeInternalError
+	}
+	return CodeBadRequest
+}
+
+// lookupCode returns the code of the error set by NewError or found among the use case sentinels
+func lookupCode(err error) (ErrorCode, bool) {
+	var customError *CustomError
+	if errors.As(err, &customError) && customError.Code != "" {
+		return customError.Code, true
+	}
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			return s.code, true
+		}
+	}
+	return "", false
+}
+
+// message is the text of the code in the language, english is the fallback
+func message(code ErrorCode, lang string) string {
+	messages := catalogue[code].messages
+	if m, ok := messages[lang]; ok {
+		return m
+	}
+	return messages[defaultLanguage]
+}
@@ -1,15 +1,27 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/validator"
+	"net"
+	"net/http"
+)
 
 type ErrorResponse struct {
-	Message    string `json:"message"`
-	Success    bool   `json:"success"`
-	StatusCode int    `json:"statusCode"`
+	Code       ErrorCode               `json:"code"`
+	Message    string                  `json:"message"`
+	Success    bool                    `json:"success"`
+	StatusCode int                     `json:"statusCode"`
+	Errors     []*validator.FieldError `json:"errors,omitempty"`
 }
 
 type CustomError struct {
 	StatusCode int `json:"statusCode"`
+	Code       ErrorCode
 	Err        error
 }
 
@@ -20,6 +32,81 @@ func NewCustomError(err error, statusCode int) error {
 	}
 }
 
+// NewError returns the error of the catalogue with its status code
+func NewError(code ErrorCode) error {
+	return &CustomError{
+		StatusCode: catalogue[code].status,
+		Code:       code,
+		Err:        errors.New(message(code, defaultLanguage)),
+	}
+}
+
 func (e *CustomError) Error() string {
 	return fmt.Sprintf("%s, status code: %d", e.Err, e.StatusCode)
 }
+
+// newErrorResponse resolves the code, the status and the localized message of the error. The catalogued
+// errors take their status from the catalogue, the internal ones are hidden behind a generic 500.
+func newErrorResponse(err error, httpStatusCode int, lang string) ErrorResponse {
+	var validationErrors validator.Errors
+	if errors.As(err, &validationErrors) {
+		return ErrorResponse{
+			Code:       CodeValidationFailed,
+			Message:    message(CodeValidationFailed, lang),
+			Success:    false,
+			StatusCode: http.StatusBadRequest,
+			Errors:     localizeFields(validationErrors, lang),
+		}
+	}
+	if code, ok := lookupCode(err); ok {
+		return ErrorResponse{
+			Code:       code,
+			Message:    message(code, lang),
+			Success:    false,
+			StatusCode: catalogue[code].status,
+		}
+	}
+	msg := err.Error()
+	var customError *CustomError
+	if errors.As(err, &customError) {
+		httpStatusCode = customError.StatusCode
+		msg = customError.Err.Error()
+	}
+	if httpStatusCode >= http.StatusInternalServerError || isInternal(err) {
+		return ErrorResponse{
+			Code:       CodeInternalError,
+			Message:    message(CodeInternalError, lang),
+			Success:    false,
+			StatusCode: http.StatusInternalServerError,
+		}
+	}
+	return ErrorResponse{
+		Code:       codeByStatus(httpStatusCode),
+		Message:    msg,
+		Success:    false,
+		StatusCode: httpStatusCode,
+	}
+}
+
+// isInternal reports the errors of the database and the network that must not reach the client
+func isInternal(err error) bool {
+	var sqlError interface{ SQLState() string }
+	var netError net.Error
+	return errors.As(err, &sqlError) || errors.As(err, &netError) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, sql.ErrTxDone) || errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func localizeFields(errs validator.Errors, lang string) []*validator.FieldError {
+	localized := make([]*validator.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		l := *fe
+		if templates, ok := fieldMessages[fe.Code]; ok {
+			if template, ok := templates[lang]; ok {
+				l.Message = validator.Format(template, fe.Param)
+			}
+		}
+		localized = append(localized, &l)
+	}
+	return localized
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+const defaultLanguage = "en"
+
+var languages = []string{"en", "ru"}
+
+// language picks the language of the error messages: the telegram language_code of the mini app user
+// goes first, then the Accept-Language header
+func language(ctf *fiber.Ctx) string {
+	initData, ok := ctf.UserContext().Value(enums.ContextKeyTelegramInitData).(*entity.TelegramInitData)
+	if ok && initData.User != nil {
+		code, _, _ := strings.Cut(strings.ToLower(initData.User.LanguageCode), "-")
+		if slices.Contains(languages, code) {
+			return code
+		}
+	}
+	if lang := ctf.AcceptsLanguages(languages...); lang != "" {
+		return lang
+	}
+	return defaultLanguage
+}
@@ -1,27 +1,13 @@
 package api
 
 import (
-	"errors"
 	"github.com/gofiber/fiber/v2"
 	"net/http"
 )
 
 func WrapError(ctf *fiber.Ctx, err error, httpStatusCode int) error {
-	var customError *CustomError
-	if errors.As(err, &customError) {
-		msg := ErrorResponse{
-			StatusCode: customError.StatusCode,
-			Success:    false,
-			Message:    customError.Err.Error(),
-		}
-		return ctf.Status(customError.StatusCode).JSON(msg)
-	}
-	msg := ErrorResponse{
-		StatusCode: httpStatusCode,
-		Success:    false,
-		Message:    err.Error(),
-	}
-	return ctf.Status(httpStatusCode).JSON(msg)
+	msg := newErrorResponse(err, httpStatusCode, language(ctf))
+	return ctf.Status(msg.StatusCode).JSON(msg)
 }
 
 func WrapOk(ctf *fiber.Ctx, data interface{}) error {
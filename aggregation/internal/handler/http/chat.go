@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ChatHandler struct {
+	logger logger.Logger
+	uc     *usecases.ChatUseCases
+	puc    *usecases.ProfileUseCases
+}
+
+func NewChatHandler(l logger.Logger, uc *usecases.ChatUseCases, puc *usecases.ProfileUseCases) *ChatHandler {
+	return &ChatHandler{logger: l, uc: uc, puc: puc}
+}
+
+func (h *ChatHandler) AddMessageHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/message/add")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestAddMessage{}
+		if err := parseBody(ctf, &req); err != nil {
+			h.logger.Debug("error func AddMessageHandler, method parseBody by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func AddMessageHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.SendMessage(ctx, p, req.ReceiverID, req.Message)
+		if err != nil {
+			h.logger.Debug("error func AddMessageHandler, method SendMessage by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapCreated(ctf, response)
+	}
+}
+
+func (h *ChatHandler) GetConversationListHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/conversation/list")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		params := entity.QueryParamsConversationList{}
+		if err := ctf.QueryParser(&params); err != nil {
+			h.logger.Debug("error func GetConversationListHandler, method QueryParser by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func GetConversationListHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		err = h.puc.UpdateLastOnline(ctx, p.ID)
+		if err != nil {
+			h.logger.Debug("error func GetConversationListHandler, method UpdateLastOnline by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.SelectConversationList(ctx, p.ID, &params)
+		if err != nil {
+			h.logger.Debug("error func GetConversationListHandler, method SelectConversationList by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *ChatHandler) GetMessageListHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/message/list")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		params := entity.QueryParamsMessageList{}
+		if err := ctf.QueryParser(&params); err != nil {
+			h.logger.Debug("error func GetMessageListHandler, method QueryParser by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func GetMessageListHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.GetMessageList(ctx, p, &params)
+		if err != nil {
+			h.logger.Debug("error func GetMessageListHandler, method GetMessageList by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *ChatHandler) UpdateMessageHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("PUT /api/v1/message/update")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestUpdateMessage{}
+		if err := parseBody(ctf, &req); err != nil {
+			h.logger.Debug("error func UpdateMessageHandler, method parseBody by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func UpdateMessageHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.EditMessage(ctx, p, req.ID, req.Message)
+		if err != nil {
+			h.logger.Debug("error func UpdateMessageHandler, method EditMessage by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapCreated(ctf, response)
+	}
+}
+
+func (h *ChatHandler) DeleteMessageHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/message/delete")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestDeleteMessage{}
+		if err := parseBody(ctf, &req); err != nil {
+			h.logger.Debug("error func DeleteMessageHandler, method parseBody by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func DeleteMessageHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.DeleteMessage(ctx, p, req.ID)
+		if err != nil {
+			h.logger.Debug("error func DeleteMessageHandler, method DeleteMessage by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapCreated(ctf, response)
+	}
+}
+
+func (h *ChatHandler) ReadMessageHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/message/read")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestReadMessage{}
+		if err := parseBody(ctf, &req); err != nil {
+			h.logger.Debug("error func ReadMessageHandler, method parseBody by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		p, err := findViewer(ctx, ctf, h.puc)
+		if err != nil {
+			h.logger.Debug("error func ReadMessageHandler, method findViewer by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.ReadMessages(ctx, p, req.ConversationID, req.MessageID)
+		if err != nil {
+			h.logger.Debug("error func ReadMessageHandler, method ReadMessages by path"+
+				" internal/handler/chat/chat.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type OutboxHandler struct {
+	logger logger.Logger
+	uc     *usecases.OutboxUseCases
+}
+
+func NewOutboxHandler(l logger.Logger, uc *usecases.OutboxUseCases) *OutboxHandler {
+	return &OutboxHandler{logger: l, uc: uc}
+}
+
+func (h *OutboxHandler) GetOutboxListHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/admin/outbox/list")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		params := entity.QueryParamsOutboxList{}
+		if err := ctf.QueryParser(&params); err != nil {
+			h.logger.Debug("error func GetOutboxListHandler, method QueryParser by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, err := h.uc.SelectList(ctx, &params)
+		if err != nil {
+			h.logger.Debug("error func GetOutboxListHandler, method SelectList by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *OutboxHandler) GetOutboxByIDHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/admin/outbox/detail/:id")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		idStr := ctf.Params("id")
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			h.logger.Debug("error func GetOutboxByIDHandler, method ParseUint by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, isExist, err := h.uc.FindByID(ctx, id)
+		if err != nil {
+			h.logger.Debug("error func GetOutboxByIDHandler, method FindByID by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		if !isExist {
+			err := api.NewError(api.CodeNotificationNotFound)
+			return api.WrapError(ctf, err, http.StatusNotFound)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *OutboxHandler) ReplayOutboxHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("POST /api/v1/admin/outbox/replay")
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		req := entity.RequestReplayOutbox{}
+		if err := ctf.BodyParser(&req); err != nil {
+			h.logger.Debug("error func ReplayOutboxHandler, method BodyParser by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		id, err := strconv.ParseUint(req.ID, 10, 64)
+		if err != nil {
+			h.logger.Debug("error func ReplayOutboxHandler, method ParseUint by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		response, isExist, err := h.uc.Replay(ctx, id)
+		if err != nil {
+			h.logger.Debug("error func ReplayOutboxHandler, method Replay by path"+
+				" internal/handler/outbox/outbox.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		if !isExist {
+			err := api.NewError(api.CodeNotificationNotFound)
+			return api.WrapError(ctf, err, http.StatusNotFound)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// parseBody decodes the request body into the DTO and checks the rules of its validate tags
+func parseBody(ctf *fiber.Ctx, out interface{}) error {
+	if err := ctf.BodyParser(out); err != nil {
+		return decodeError(err)
+	}
+	return validator.Struct(out)
+}
+
+// parseQuery decodes the query string into the DTO and checks the rules of its validate tags
+func parseQuery(ctf *fiber.Ctx, out interface{}) error {
+	if err := ctf.QueryParser(out); err != nil {
+		return decodeError(err)
+	}
+	return validator.Struct(out)
+}
+
+// decodeError reports the values that can't be converted to the type of the field as the field errors
+func decodeError(err error) error {
+	var multiError fiber.MultiError
+	if errors.As(err, &multiError) {
+		errs := make(validator.Errors, 0, len(multiError))
+		for key := range multiError {
+			errs = append(errs, validator.NewFieldError(key, validator.CodeInvalid, ""))
+		}
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return errs
+	}
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return validator.Errors{validator.NewFieldError(typeError.Field, validator.CodeInvalid, "")}
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	socketLocalsProfileID = "profileID"
+	socketPingPeriod      = 30 * time.Second
+	socketWriteTimeout    = 10 * time.Second
+	socketBufferSize      = 32
+	hubBufferSize         = 256
+)
+
+type socketClient struct {
+	conn   *websocket.Conn
+	events chan *entity.Event
+}
+
+// SocketHandler - websocket gateway, it fans hub events out to the sockets of the event's profile
+type SocketHandler struct {
+	logger  logger.Logger
+	uc      *usecases.ProfileUseCases
+	mu      sync.RWMutex
+	clients map[uint64]map[*socketClient]struct{}
+}
+
+func NewSocketHandler(l logger.Logger, uc *usecases.ProfileUseCases) *SocketHandler {
+	return &SocketHandler{
+		logger:  l,
+		uc:      uc,
+		clients: make(map[uint64]map[*socketClient]struct{}),
+	}
+}
+
+// Run delivers hub events to the connected sockets until the context is done
+func (h *SocketHandler) Run(ctx context.Context) {
+	sub := h.uc.Hub.Subscribe(hubBufferSize)
+	defer h.uc.Hub.Unsubscribe(sub)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-sub.Events:
+			h.mu.RLock()
+			for c := range h.clients[e.ProfileID] {
+				select {
+				case c.events <- e:
+				default:
+					h.logger.Debug("socket buffer is full, event is dropped",
+						zap.Uint64("profileId", e.ProfileID), zap.String("type", e.Type))
+				}
+			}
+			h.mu.RUnlock()
+		}
+	}
+}
+
+// UpgradeHandler resolves the profile of the telegram user before the connection is upgraded
+func (h *SocketHandler) UpgradeHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		h.logger.Info("GET /api/v1/ws")
+		if !websocket.IsWebSocketUpgrade(ctf) {
+			return api.WrapError(ctf, errors.New("websocket upgrade required"), http.StatusUpgradeRequired)
+		}
+		ctx, cancel := context.WithTimeout(ctf.Context(), TimeoutDuration)
+		defer cancel()
+		p, err := findViewer(ctx, ctf, h.uc)
+		if err != nil {
+			h.logger.Debug("error func UpgradeHandler, method findViewer by path"+
+				" internal/handler/socket/socket.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusNotFound)
+		}
+		if p.IsDeleted || p.IsBlocked {
+			err := api.NewCustomError(errors.New("profile has been deleted or blocked"), http.StatusForbidden)
+			return api.WrapError(ctf, err, http.StatusForbidden)
+		}
+		ctf.Locals(socketLocalsProfileID, p.ID)
+		return ctf.Next()
+	}
+}
+
+func (h *SocketHandler) EventsHandler() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		profileID := conn.Locals(socketLocalsProfileID).(uint64)
+		c := &socketClient{
+			conn:   conn,
+			events: make(chan *entity.Event, socketBufferSize),
+		}
+		if isFirst := h.register(profileID, c); isFirst {
+			h.publishPresence(profileID, true)
+		}
+		done := make(chan struct{})
+		go h.writeEvents(c, done)
+		// the client isn't expected to send anything, reading only detects the closed connection
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				break
+			}
+		}
+		close(done)
+		if isLast := h.unregister(profileID, c); isLast {
+			h.publishPresence(profileID, false)
+		}
+	})
+}
+
+func (h *SocketHandler) writeEvents(c *socketClient, done <-chan struct{}) {
+	ticker := time.NewTicker(socketPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case e := <-c.events:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+			if err := c.conn.WriteJSON(e); err != nil {
+				h.logger.Debug("error func writeEvents, method WriteJSON by path"+
+					" internal/handler/socket/socket.go", zap.Error(err))
+				_ = c.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(socketWriteTimeout)
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// register returns true for the first socket of the profile
+func (h *SocketHandler) register(profileID uint64, c *socketClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clients, ok := h.clients[profileID]
+	if !ok {
+		clients = make(map[*socketClient]struct{})
+		h.clients[profileID] = clients
+	}
+	clients[c] = struct{}{}
+	return len(clients) == 1
+}
+
+// unregister returns true when the last socket of the profile is gone
+func (h *SocketHandler) unregister(profileID uint64, c *socketClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clients := h.clients[profileID]
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.clients, profileID)
+		return true
+	}
+	return false
+}
+
+// publishPresence tells the profile's matches that it went online or offline
+func (h *SocketHandler) publishPresence(profileID uint64, isOnline bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutDuration)
+	defer cancel()
+	if err := h.uc.UpdateLastOnline(ctx, profileID); err != nil {
+		h.logger.Debug("error func publishPresence, method UpdateLastOnline by path"+
+			" internal/handler/socket/socket.go", zap.Error(err))
+	}
+	matchedUserIDs, err := h.uc.SelectListMatchedUserID(ctx, profileID)
+	if err != nil {
+		h.logger.Debug("error func publishPresence, method SelectListMatchedUserID by path"+
+			" internal/handler/socket/socket.go", zap.Error(err))
+		return
+	}
+	payload := &entity.PresencePayload{
+		ProfileID:  profileID,
+		IsOnline:   isOnline,
+		LastOnline: time.Now().UTC(),
+	}
+	for _, id := range matchedUserIDs {
+		h.uc.Hub.Publish(&entity.Event{
+			Type:      entity.EventTypePresence,
+			ProfileID: id,
+			Payload:   payload,
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+// getTelegramInitData returns the init data verified by the telegram auth middleware
+func getTelegramInitData(ctf *fiber.Ctx) (*entity.TelegramInitData, error) {
+	initData, ok := ctf.UserContext().Value(enums.ContextKeyTelegramInitData).(*entity.TelegramInitData)
+	if !ok {
+		return nil, api.NewError(api.CodeTelegramInitDataRequired)
+	}
+	return initData, nil
+}
+
+// findViewer returns the profile of the telegram user that sent the request
+func findViewer(ctx context.Context, ctf *fiber.Ctx, uc *usecases.ProfileUseCases) (*entity.Profile, error) {
+	initData, err := getTelegramInitData(ctf)
+	if err != nil {
+		return nil, err
+	}
+	p, err := uc.FindByTelegramId(ctx, initData.User.ID)
+	if errors.Is(err, usecases.ErrNotFound) {
+		return nil, api.NewError(api.CodeProfileNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// getPrincipal returns the administrator authorized by the realm role or the profile of the telegram user
+func getPrincipal(ctx context.Context, ctf *fiber.Ctx, uc *usecases.ProfileUseCases) (*entity.Principal, error) {
+	if role, ok := ctf.UserContext().Value(enums.ContextKeyRealmRole).(string); ok && role == enums.RealmRoleAdmin {
+		return &entity.Principal{IsAdmin: true}, nil
+	}
+	p, err := findViewer(ctx, ctf, uc)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Principal{ProfileID: p.ID}, nil
+}
@@ -4,8 +4,12 @@ import (
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/EvgeniyBudaev/gravity/aggregation/pkg/jwt"
 	"github.com/gofiber/fiber/v2"
+	golangJwt "github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
 )
@@ -51,6 +55,13 @@ func (h *UserHandler) UpdateUserHandler() fiber.Handler {
 				zap.Error(err))
 			return api.WrapError(ctf, err, http.StatusBadRequest)
 		}
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func UpdateUserHandle, method getUserID by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		request.ID = &userID
 		response, err := h.uc.UpdateUser(ctx, request)
 		if err != nil {
 			h.logger.Debug("error func UpdateUserHandle, method UpdateUser by path internal/handler/user/user.go",
@@ -65,13 +76,13 @@ func (h *UserHandler) DeleteUserHandler() fiber.Handler {
 	return func(ctf *fiber.Ctx) error {
 		var ctx = ctf.UserContext()
 		h.logger.Info("POST /api/v1/user/delete")
-		var request = entity.RequestDeleteUser{}
-		err := ctf.BodyParser(&request)
+		userID, err := getUserID(ctf)
 		if err != nil {
-			h.logger.Debug("error func DeleteUserHandler, method BodyParser by path internal/handler/user/user.go",
+			h.logger.Debug("error func DeleteUserHandler, method getUserID by path internal/handler/user/user.go",
 				zap.Error(err))
-			return api.WrapError(ctf, err, http.StatusBadRequest)
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
 		}
+		request := entity.RequestDeleteUser{ID: &userID}
 		err = h.uc.DeleteUser(ctx, request)
 		if err != nil {
 			h.logger.Debug("error func DeleteUserHandler, method DeleteUser by path internal/handler/user/user.go",
@@ -85,12 +96,12 @@ func (h *UserHandler) DeleteUserHandler() fiber.Handler {
 func (h *UserHandler) GetUserListHandler() fiber.Handler {
 	return func(ctf *fiber.Ctx) error {
 		var ctx = ctf.UserContext()
-		h.logger.Info("GET /api/v1/user/list")
+		h.logger.Info("GET /api/v1/admin/user/list")
 		query := entity.QueryParamsUserList{}
 		if err := ctf.QueryParser(&query); err != nil {
 			h.logger.Debug("error func GetUserListHandler, method QueryParser by path"+
 				" internal/handler/user/user.go", zap.Error(err))
-			return err
+			return api.WrapError(ctf, err, http.StatusBadRequest)
 		}
 		response, err := h.uc.GetUserList(ctx, query)
 		if err != nil {
@@ -101,3 +112,143 @@ func (h *UserHandler) GetUserListHandler() fiber.Handler {
 		return api.WrapOk(ctf, response)
 	}
 }
+
+func (h *UserHandler) GetMeHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("GET /api/v1/user/me")
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func GetMeHandler, method getUserID by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		response, err := h.uc.GetMe(ctx, userID)
+		if err != nil {
+			h.logger.Debug("error func GetMeHandler, method GetMe by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+func (h *UserHandler) ChangePasswordHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("POST /api/v1/user/password/change")
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func ChangePasswordHandler, method getUserID by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		if err := h.uc.ChangePassword(ctx, userID); err != nil {
+			h.logger.Debug("error func ChangePasswordHandler, method ChangePassword by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, nil)
+	}
+}
+
+func (h *UserHandler) ResetPasswordHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("POST /api/v1/user/password/reset")
+		var request = entity.RequestResetPassword{}
+		if err := ctf.BodyParser(&request); err != nil {
+			h.logger.Debug("error func ResetPasswordHandler, method BodyParser by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		if err := h.uc.ResetPassword(ctx, request); err != nil {
+			h.logger.Debug("error func ResetPasswordHandler, method ResetPassword by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, nil)
+	}
+}
+
+func (h *UserHandler) VerifyEmailHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("POST /api/v1/user/email/verify")
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func VerifyEmailHandler, method getUserID by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		if err := h.uc.SendVerifyEmail(ctx, userID); err != nil {
+			h.logger.Debug("error func VerifyEmailHandler, method SendVerifyEmail by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, nil)
+	}
+}
+
+func (h *UserHandler) LogoutAllHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("POST /api/v1/user/logout/all")
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func LogoutAllHandler, method getUserID by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		if err := h.uc.LogoutAll(ctx, userID); err != nil {
+			h.logger.Debug("error func LogoutAllHandler, method LogoutAll by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, nil)
+	}
+}
+
+// LinkProfileHandler binds the keycloak user to the mini app profile, the request carries both the token
+// and the telegram init data in the query
+func (h *UserHandler) LinkProfileHandler() fiber.Handler {
+	return func(ctf *fiber.Ctx) error {
+		var ctx = ctf.UserContext()
+		h.logger.Info("POST /api/v1/user/profile/link")
+		userID, err := getUserID(ctf)
+		if err != nil {
+			h.logger.Debug("error func LinkProfileHandler, method getUserID by path internal/handler/user/user.go",
+				zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		initData, err := getTelegramInitData(ctf)
+		if err != nil {
+			h.logger.Debug("error func LinkProfileHandler, method getTelegramInitData by path"+
+				" internal/handler/user/user.go", zap.Error(err))
+			return api.WrapError(ctf, err, http.StatusUnauthorized)
+		}
+		response, err := h.uc.LinkProfile(ctx, userID, initData.User.ID)
+		if err != nil {
+			h.logger.Debug("error func LinkProfileHandler, method LinkProfile by path internal/handler/user/user.go",
+				zap.Error(err))
+			if errors.Is(err, usecases.ErrNotFound) {
+				return api.WrapError(ctf, api.NewError(api.CodeProfileNotFound), http.StatusNotFound)
+			}
+			return api.WrapError(ctf, err, http.StatusBadRequest)
+		}
+		return api.WrapOk(ctf, response)
+	}
+}
+
+// getUserID returns the keycloak user id, the subject of the token verified by the JWT middleware
+func getUserID(ctf *fiber.Ctx) (string, error) {
+	claims, ok := ctf.UserContext().Value(enums.ContextKeyClaims).(golangJwt.MapClaims)
+	if !ok {
+		return "", errors.New("token claims not found")
+	}
+	subject, err := jwt.NewHelper(claims).GetUserId()
+	if err != nil || subject == "" {
+		return "", errors.New("token subject not found")
+	}
+	return subject, nil
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
-	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
@@ -14,11 +13,18 @@ import (
 func InitFiberMiddlewares(app *fiber.App,
 	cfg *config.Config,
 	l logger.Logger,
+	tokenRetrospector TokenRetrospector,
 	grp fiber.Router,
 	imh *http.UserHandler,
 	ph *http.ProfileHandler,
-	initPublicRoutes func(grp fiber.Router, imh *http.UserHandler, ph *http.ProfileHandler),
-	initProtectedRoutes func(grp fiber.Router, ph *http.ProfileHandler)) {
+	ch *http.ChatHandler,
+	sh *http.SocketHandler,
+	oh *http.OutboxHandler,
+	ah *http.AdminHandler,
+	initPublicRoutes func(grp fiber.Router, ta fiber.Handler, imh *http.UserHandler, ph *http.ProfileHandler,
+		ch *http.ChatHandler, sh *http.SocketHandler),
+	initProtectedRoutes func(grp fiber.Router, l logger.Logger, auth fiber.Handler, ta fiber.Handler,
+		imh *http.UserHandler, ph *http.ProfileHandler, oh *http.OutboxHandler, ah *http.AdminHandler)) {
 	app.Use(requestid.New())
 	app.Use(func(c *fiber.Ctx) error {
 		// get the request id that was added by requestid middleware
@@ -28,10 +34,10 @@ func InitFiberMiddlewares(app *fiber.App,
 		c.SetUserContext(ctx)
 		return c.Next()
 	})
-	// routes that don't require a JWT token
-	initPublicRoutes(grp, imh, ph)
-	tokenRetrospector := usecases.NewIdentity(cfg, l)
-	app.Use(NewJwtMiddleware(cfg, tokenRetrospector, l))
-	// routes that require authentication/authorization
-	initProtectedRoutes(grp, ph)
+	// routes that don't require a JWT token, the mini app ones are authenticated by the telegram init data
+	telegramAuth := NewTelegramAuthMiddleware(cfg, l)
+	initPublicRoutes(grp, telegramAuth, imh, ph, ch, sh)
+	// routes that require authentication/authorization, the JWT middleware guards only their groups
+	jwtAuth := NewJwtMiddleware(cfg, tokenRetrospector, l)
+	initProtectedRoutes(grp, l, jwtAuth, telegramAuth, imh, ph, oh, ah)
 }
@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/config"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	introspectionSweepInterval = time.Minute
+	introspectionStatsInterval = 5 * time.Minute
+)
+
+type introspectionEntry struct {
+	active    bool
+	expiresAt time.Time
+}
+
+// introspectionCache keeps the keycloak introspection results keyed by the token hash so that a protected
+// request doesn't need a round trip to keycloak every time. An active result lives for the configured ttl,
+// an inactive one can't become active again and is kept until the token expires. Entries never outlive exp.
+type introspectionCache struct {
+	logger            logger.Logger
+	tokenRetrospector TokenRetrospector
+	trustSignature    bool
+	ttl               time.Duration
+	size              int
+	mu                sync.Mutex
+	entries           map[[sha256.Size]byte]introspectionEntry
+	hits              atomic.Uint64
+	misses            atomic.Uint64
+}
+
+func newIntrospectionCache(cfg *config.Config, tokenRetrospector TokenRetrospector, l logger.Logger) *introspectionCache {
+	c := &introspectionCache{
+		logger:            l,
+		tokenRetrospector: tokenRetrospector,
+		trustSignature:    cfg.JWTTrustSignature,
+		ttl:               cfg.IntrospectionCacheTTL,
+		size:              cfg.IntrospectionCacheSize,
+		entries:           make(map[[sha256.Size]byte]introspectionEntry),
+	}
+	if c.trustSignature {
+		l.Info("token introspection is disabled, tokens are trusted by signature")
+	} else if c.ttl > 0 && c.size > 0 {
+		go c.sweep()
+	}
+	return c
+}
+
+// IsActive reports whether the token wasn't revoked. exp is the token expiration, zero when the claim is absent.
+func (c *introspectionCache) IsActive(ctx context.Context, accessToken string, exp time.Time) (bool, error) {
+	if c.trustSignature {
+		return true, nil
+	}
+	key := sha256.Sum256([]byte(accessToken))
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		c.hits.Add(1)
+		return entry.active, nil
+	}
+	c.misses.Add(1)
+	rptResult, err := c.tokenRetrospector.RetrospectToken(ctx, accessToken)
+	if err != nil {
+		c.logger.Debug("error while IsActive. Error in RetrospectToken", zap.Error(err))
+		return false, err
+	}
+	active := rptResult.Active != nil && *rptResult.Active
+	c.store(key, active, now, exp)
+	return active, nil
+}
+
+// Stats returns the number of cache hits and misses since the start
+func (c *introspectionCache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+func (c *introspectionCache) store(key [sha256.Size]byte, active bool, now, exp time.Time) {
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	expiresAt := exp
+	if active || exp.IsZero() {
+		expiresAt = now.Add(c.ttl)
+		if !exp.IsZero() && exp.Before(expiresAt) {
+			expiresAt = exp
+		}
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		c.evictExpired(now)
+		// still full, drop an arbitrary entry, map iteration order is random
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = introspectionEntry{active: active, expiresAt: expiresAt}
+}
+
+func (c *introspectionCache) evictExpired(now time.Time) {
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *introspectionCache) sweep() {
+	ticker := time.NewTicker(introspectionSweepInterval)
+	defer ticker.Stop()
+	lastStats := time.Now()
+	for now := range ticker.C {
+		c.mu.Lock()
+		c.evictExpired(now)
+		size := len(c.entries)
+		c.mu.Unlock()
+		if now.Sub(lastStats) >= introspectionStatsInterval {
+			lastStats = now
+			hits, misses := c.Stats()
+			c.logger.Info("introspection cache stats",
+				zap.Uint64("hits", hits), zap.Uint64("misses", misses), zap.Int("size", size))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/config"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/MicahParks/keyfunc/v2"
+	golangJwt "github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	jwksRefreshRateLimit = 5 * time.Minute
+	jwksRefreshTimeout   = 10 * time.Second
+	jwksRetryMinDelay    = 5 * time.Second
+	jwksRetryMaxDelay    = 5 * time.Minute
+)
+
+var errNoSigningKey = errors.New("no signing key available to verify the token")
+
+// keySet resolves the key a keycloak token was signed with. Keys are looked up by kid in the realm JWKS,
+// the static RS256 key from the config is used when the JWKS is not available or does not know the kid.
+type keySet struct {
+	logger    logger.Logger
+	jwks      atomic.Pointer[keyfunc.JWKS]
+	staticKey *rsa.PublicKey
+}
+
+func newKeySet(cfg *config.Config, l logger.Logger) (*keySet, error) {
+	ks := &keySet{logger: l}
+	if cfg.RealmRS256PublicKey != "" {
+		publicKey, err := parseKeycloakRSAPublicKey(cfg.RealmRS256PublicKey, l)
+		if err != nil {
+			l.Debug("error while newKeySet. Error in parseKeycloakRSAPublicKey", zap.Error(err))
+		} else {
+			ks.staticKey = publicKey
+		}
+	}
+	jwksURL := realmJWKSURL(cfg)
+	if jwksURL == "" && ks.staticKey == nil {
+		return nil, fmt.Errorf("neither keycloak base url and realm nor a valid realm public key are configured")
+	}
+	if jwksURL != "" {
+		options := keyfunc.Options{
+			RefreshErrorHandler: func(err error) {
+				l.Error("error while refreshing JWKS", zap.String("url", jwksURL), zap.Error(err))
+			},
+			RefreshInterval:   cfg.JWKSRefreshInterval,
+			RefreshRateLimit:  jwksRefreshRateLimit,
+			RefreshTimeout:    jwksRefreshTimeout,
+			RefreshUnknownKID: true,
+		}
+		jwks, err := keyfunc.Get(jwksURL, options)
+		if err != nil {
+			l.Error("error while newKeySet. Error in keyfunc.Get, retrying in background",
+				zap.String("url", jwksURL), zap.Error(err))
+			go ks.fetchUntilReady(jwksURL, options)
+		} else {
+			ks.jwks.Store(jwks)
+		}
+	}
+	return ks, nil
+}
+
+// fetchUntilReady retries the initial JWKS download with a growing delay, the static key serves requests meanwhile
+func (ks *keySet) fetchUntilReady(jwksURL string, options keyfunc.Options) {
+	delay := jwksRetryMinDelay
+	for {
+		time.Sleep(delay)
+		jwks, err := keyfunc.Get(jwksURL, options)
+		if err == nil {
+			ks.jwks.Store(jwks)
+			ks.logger.Info("JWKS fetched", zap.String("url", jwksURL), zap.Strings("kids", jwks.KIDs()))
+			return
+		}
+		ks.logger.Error("error while fetchUntilReady. Error in keyfunc.Get", zap.String("url", jwksURL), zap.Error(err))
+		delay = min(delay*2, jwksRetryMaxDelay)
+	}
+}
+
+// Keyfunc matches the signature of golangJwt.Keyfunc
+func (ks *keySet) Keyfunc(token *golangJwt.Token) (interface{}, error) {
+	if jwks := ks.jwks.Load(); jwks != nil {
+		key, err := jwks.Keyfunc(token)
+		if err == nil {
+			return key, nil
+		}
+		if ks.staticKey == nil {
+			return nil, err
+		}
+		ks.logger.Debug("error while Keyfunc. Error in jwks.Keyfunc, falling back to static key", zap.Error(err))
+	}
+	if ks.staticKey == nil {
+		return nil, errNoSigningKey
+	}
+	if token.Method.Alg() != golangJwt.SigningMethodRS256.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method=%v", token.Header["alg"])
+	}
+	return ks.staticKey, nil
+}
+
+func realmJWKSURL(cfg *config.Config) string {
+	if cfg.BaseUrl == "" || cfg.Realm == "" {
+		return ""
+	}
+	return strings.TrimRight(cfg.BaseUrl, "/") + "/realms/" + cfg.Realm + "/protocol/openid-connect/certs"
+}
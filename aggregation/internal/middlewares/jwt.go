@@ -16,6 +16,8 @@ import (
 	golangJwt "github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 	"net/http"
+	"slices"
+	"time"
 )
 
 type TokenRetrospector interface {
@@ -23,42 +25,71 @@ type TokenRetrospector interface {
 }
 
 func NewJwtMiddleware(config *config.Config, tokenRetrospector TokenRetrospector, logger logger.Logger) fiber.Handler {
-	base64Str := config.RealmRS256PublicKey
-	publicKey, err := parseKeycloakRSAPublicKey(base64Str, logger)
+	keys, err := newKeySet(config, logger)
 	if err != nil {
-		logger.Debug("error while NewJwtMiddleware. Error in parseKeycloakRSAPublicKey", zap.Error(err))
+		logger.Debug("error while NewJwtMiddleware. Error in newKeySet", zap.Error(err))
 		panic(err)
 	}
+	introspection := newIntrospectionCache(config, tokenRetrospector, logger)
 	return contribJwt.New(contribJwt.Config{
-		SigningKey: contribJwt.SigningKey{
-			JWTAlg: contribJwt.RS256,
-			Key:    publicKey,
-		},
+		KeyFunc: keys.Keyfunc,
 		SuccessHandler: func(c *fiber.Ctx) error {
-			return successHandler(c, tokenRetrospector, logger)
+			return successHandler(c, config, introspection, logger)
 		},
 	})
 }
 
-func successHandler(c *fiber.Ctx, tokenRetrospector TokenRetrospector, logger logger.Logger) error {
+func successHandler(
+	c *fiber.Ctx, config *config.Config, introspection *introspectionCache, logger logger.Logger) error {
 	jwtToken := c.Locals("user").(*golangJwt.Token)
 	claims := jwtToken.Claims.(golangJwt.MapClaims)
+	if err := validateIssuerAndAudience(claims, config); err != nil {
+		logger.Debug("error while successHandler. Error in validateIssuerAndAudience", zap.Error(err))
+		return r.WrapError(c, err, http.StatusUnauthorized)
+	}
 	var ctx = c.UserContext()
 	var contextWithClaims = context.WithValue(ctx, enums.ContextKeyClaims, claims)
 	c.SetUserContext(contextWithClaims)
-	rptResult, err := tokenRetrospector.RetrospectToken(ctx, jwtToken.Raw)
+	var exp time.Time
+	if expirationTime, err := claims.GetExpirationTime(); err == nil && expirationTime != nil {
+		exp = expirationTime.Time
+	}
+	active, err := introspection.IsActive(ctx, jwtToken.Raw, exp)
 	if err != nil {
-		logger.Debug("error while successHandler. Error in RetrospectToken", zap.Error(err))
-		return err
+		// keycloak can't tell whether the token was revoked, the client should retry rather than log in again
+		logger.Debug("error while successHandler. Error in IsActive", zap.Error(err))
+		return r.WrapError(c, r.NewError(r.CodeUnavailable), http.StatusServiceUnavailable)
 	}
-	if !*rptResult.Active {
-		err := fmt.Errorf("token is not active")
-		logger.Debug("error while successHandler. Error in parseKeycloakRSAPublicKey", zap.Error(err))
-		return r.WrapError(c, err, http.StatusUnauthorized)
+	if !active {
+		logger.Debug("error while successHandler. Error in IsActive", zap.Error(fmt.Errorf("token is not active")))
+		return r.WrapError(c, r.NewError(r.CodeUnauthorized), http.StatusUnauthorized)
 	}
 	return c.Next()
 }
 
+// validateIssuerAndAudience checks iss and aud of the token, an empty value in the config disables the check
+func validateIssuerAndAudience(claims golangJwt.MapClaims, config *config.Config) error {
+	if config.JWTIssuer != "" {
+		issuer, err := claims.GetIssuer()
+		if err != nil {
+			return err
+		}
+		if issuer != config.JWTIssuer {
+			return fmt.Errorf("unexpected token issuer %q", issuer)
+		}
+	}
+	if config.JWTAudience != "" {
+		audience, err := claims.GetAudience()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(audience, config.JWTAudience) {
+			return fmt.Errorf("token is not issued for audience %q", config.JWTAudience)
+		}
+	}
+	return nil
+}
+
 func parseKeycloakRSAPublicKey(base64Str string, logger logger.Logger) (*rsa.PublicKey, error) {
 	buf, err := base64.StdEncoding.DecodeString(base64Str)
 	if err != nil {
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/config"
+	r "github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/gofiber/fiber/v2"
+	golangJwt "github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeRetrospector struct {
+	active bool
+	err    error
+}
+
+func (f *fakeRetrospector) RetrospectToken(
+	_ context.Context, _ string) (*gocloak.IntroSpectTokenResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &gocloak.IntroSpectTokenResult{Active: gocloak.BoolP(f.active)}, nil
+}
+
+func TestSuccessHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		retro      *fakeRetrospector
+		wantStatus int
+		wantCode   r.ErrorCode
+	}{
+		{"active", &fakeRetrospector{active: true}, http.StatusOK, ""},
+		{"inactive", &fakeRetrospector{active: false}, http.StatusUnauthorized, r.CodeUnauthorized},
+		{"keycloak unreachable", &fakeRetrospector{err: errors.New("connection refused")},
+			http.StatusServiceUnavailable, r.CodeUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			introspection := newIntrospectionCache(cfg, tt.retro, zap.NewNop())
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("user", &golangJwt.Token{Raw: "token", Claims: golangJwt.MapClaims{}})
+				return c.Next()
+			}, func(c *fiber.Ctx) error {
+				return successHandler(c, cfg, introspection, zap.NewNop())
+			})
+			app.Get("/", func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body r.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.wantCode || body.StatusCode != tt.wantStatus {
+				t.Errorf("body = %+v, want the code %s", body, tt.wantCode)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"fmt"
 	r "github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
@@ -13,15 +14,29 @@ import (
 )
 
 func NewRequiresRealmRole(role string, logger logger.Logger) fiber.Handler {
+	return NewRequiresAnyRealmRole([]string{role}, logger)
+}
+
+// NewRequiresAnyRealmRole lets the request through if the token has at least one of the realm roles
+func NewRequiresAnyRealmRole(roles []string, logger logger.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var ctx = c.UserContext()
-		claims := ctx.Value(enums.ContextKeyClaims).(golangJwt.MapClaims)
-		jwtHelper := jwt.NewHelper(claims)
-		if !jwtHelper.IsUserInRealmRole(role) {
-			err := fmt.Errorf("role authorization failed")
-			logger.Debug("error while NewRequiresRealmRole. Error in IsUserInRealmRole", zap.Error(err))
+		claims, ok := ctx.Value(enums.ContextKeyClaims).(golangJwt.MapClaims)
+		if !ok {
+			err := fmt.Errorf("token claims not found")
+			logger.Debug("error while NewRequiresAnyRealmRole. Error in Value", zap.Error(err))
 			return r.WrapError(c, err, http.StatusUnauthorized)
 		}
-		return c.Next()
+		jwtHelper := jwt.NewHelper(claims)
+		for _, role := range roles {
+			if jwtHelper.IsUserInRealmRole(role) {
+				// handlers let the role holder bypass the ownership checks
+				c.SetUserContext(context.WithValue(ctx, enums.ContextKeyRealmRole, role))
+				return c.Next()
+			}
+		}
+		err := fmt.Errorf("role authorization failed")
+		logger.Debug("error while NewRequiresAnyRealmRole. Error in IsUserInRealmRole", zap.Error(err))
+		return r.WrapError(c, err, http.StatusForbidden)
 	}
 }
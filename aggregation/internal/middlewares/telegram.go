@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/config"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	r "github.com/EvgeniyBudaev/gravity/aggregation/internal/handler/http/api/v1"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/shared/enums"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	telegramAuthScheme = "tma"
+	// browsers can't set headers on a websocket handshake, so the init data may come in the query
+	telegramInitDataQuery = "initData"
+	telegramWebAppDataKey = "WebAppData"
+)
+
+// NewTelegramAuthMiddleware verifies the init data of the telegram mini app passed as
+// "Authorization: tma <initData>" and puts it into the user context
+func NewTelegramAuthMiddleware(cfg *config.Config, logger logger.Logger) fiber.Handler {
+	secretKey := hmacSHA256([]byte(telegramWebAppDataKey), []byte(cfg.TelegramBotToken))
+	return func(c *fiber.Ctx) error {
+		raw := c.Query(telegramInitDataQuery)
+		scheme, value, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		if found && strings.EqualFold(scheme, telegramAuthScheme) {
+			raw = value
+		}
+		if raw == "" {
+			err := fmt.Errorf("telegram init data is missing")
+			logger.Debug("error while NewTelegramAuthMiddleware. Error in Get", zap.Error(err))
+			return r.WrapError(c, err, http.StatusUnauthorized)
+		}
+		initData, err := parseTelegramInitData(raw, secretKey, cfg.TelegramInitDataTTL)
+		if err != nil {
+			logger.Debug("error while NewTelegramAuthMiddleware. Error in parseTelegramInitData", zap.Error(err))
+			return r.WrapError(c, err, http.StatusUnauthorized)
+		}
+		var ctx = context.WithValue(c.UserContext(), enums.ContextKeyTelegramInitData, initData)
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// parseTelegramInitData checks the signature as described in
+// https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func parseTelegramInitData(raw string, secretKey []byte, ttl time.Duration) (*entity.TelegramInitData, error) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, fmt.Errorf("telegram init data is not signed")
+	}
+	pairs := make([]string, 0, len(values))
+	for key := range values {
+		if key == "hash" {
+			continue
+		}
+		pairs = append(pairs, key+"="+values.Get(key))
+	}
+	sort.Strings(pairs)
+	expected := hmacSHA256(secretKey, []byte(strings.Join(pairs, "\n")))
+	actual, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return nil, fmt.Errorf("telegram init data signature is invalid")
+	}
+	authDateUnix, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("telegram init data auth_date is invalid")
+	}
+	authDate := time.Unix(authDateUnix, 0).UTC()
+	if ttl > 0 && time.Since(authDate) > ttl {
+		return nil, fmt.Errorf("telegram init data has expired")
+	}
+	user := entity.TelegramUser{}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, fmt.Errorf("telegram init data user is invalid")
+	}
+	return &entity.TelegramInitData{
+		QueryID:    values.Get("query_id"),
+		User:       &user,
+		AuthDate:   authDate,
+		StartParam: values.Get("start_param"),
+	}, nil
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
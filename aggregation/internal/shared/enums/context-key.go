@@ -3,6 +3,8 @@ package enums
 type ContextKey int
 
 const (
-	ContextKeyRequestId ContextKey = iota
-	ContextKeyClaims               = iota
+	ContextKeyRequestId        ContextKey = iota
+	ContextKeyClaims                      = iota
+	ContextKeyTelegramInitData            = iota
+	ContextKeyRealmRole                   = iota
 )
@@ -0,0 +1,6 @@
+package enums
+
+const (
+	RealmRoleAdmin     = "admin"
+	RealmRoleModerator = "moderator"
+)
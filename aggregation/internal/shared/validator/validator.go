@@ -0,0 +1,267 @@
+// Package validator checks request DTOs against the rules declared in their `validate` struct tags.
+//
+// Rules are separated by commas:
+//
+//	required  - the value must not be zero
+//	omitempty - the other rules are skipped for a zero value
+//	min=N     - a number is not less than N
+//	max=N     - a number is not greater than N
+//	maxlen=N  - a string has at most N characters
+//	oneof=a b - a string is one of the listed values
+//	minage=N  - a date is at least N years ago
+//	maxage=N  - a date is at most N years ago
+//
+// Nil pointers are skipped unless the field is required, embedded structs are validated as a part of the parent.
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const tagName = "validate"
+
+const (
+	CodeRequired  = "REQUIRED"
+	CodeInvalid   = "INVALID"
+	CodeMin       = "MIN"
+	CodeMax       = "MAX"
+	CodeMaxLength = "MAX_LENGTH"
+	CodeOneOf     = "ONE_OF"
+	CodeMinAge    = "MIN_AGE"
+	CodeMaxAge    = "MAX_AGE"
+)
+
+var messages = map[string]string{
+	CodeRequired:  "is required",
+	CodeInvalid:   "has an invalid value",
+	CodeMin:       "must be at least %s",
+	CodeMax:       "must be at most %s",
+	CodeMaxLength: "must be at most %s characters long",
+	CodeOneOf:     "must be one of: %s",
+	CodeMinAge:    "age must be at least %s",
+	CodeMaxAge:    "age must be at most %s",
+}
+
+// FieldError - the failed rule of the field, Code is stable while Message is english and can be localized
+// by the Code and the Param of the rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewFieldError returns the field error with the english message of the code
+func NewFieldError(field, code, param string) *FieldError {
+	return &FieldError{Field: field, Code: code, Param: param, Message: Format(messages[code], param)}
+}
+
+// Format puts the rule parameter into the message template
+func Format(template, param string) string {
+	if param == "" {
+		return template
+	}
+	return fmt.Sprintf(template, param)
+}
+
+// Errors - all the fields that failed the validation
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+type field struct {
+	index     int
+	name      string
+	embedded  bool
+	omitempty bool
+	required  bool
+	rules     []rule
+}
+
+var cache sync.Map // reflect.Type -> []field
+
+// Struct validates the struct or the pointer to it, the result is Errors or nil
+func Struct(s interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(s))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: %T is not a struct", s)
+	}
+	var errs Errors
+	validateStruct(v, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, errs *Errors) {
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		if f.embedded {
+			if fv = reflect.Indirect(fv); fv.IsValid() {
+				validateStruct(fv, errs)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				if f.required {
+					*errs = append(*errs, NewFieldError(f.name, CodeRequired, ""))
+				}
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.IsZero() {
+			if f.required {
+				*errs = append(*errs, NewFieldError(f.name, CodeRequired, ""))
+				continue
+			}
+			if f.omitempty {
+				continue
+			}
+		}
+		for _, r := range f.rules {
+			if code, param := check(r, fv); code != "" {
+				*errs = append(*errs, NewFieldError(f.name, code, param))
+				break
+			}
+		}
+	}
+}
+
+// check returns the code and the parameter of the broken rule, the empty code when the value is valid
+func check(r rule, v reflect.Value) (string, string) {
+	switch r.name {
+	case "min", "max":
+		n, ok := number(v)
+		if !ok {
+			return CodeInvalid, ""
+		}
+		limit, _ := strconv.ParseFloat(r.param, 64)
+		if r.name == "min" && n < limit {
+			return CodeMin, r.param
+		}
+		if r.name == "max" && n > limit {
+			return CodeMax, r.param
+		}
+	case "maxlen":
+		limit, _ := strconv.Atoi(r.param)
+		if utf8.RuneCountInString(v.String()) > limit {
+			return CodeMaxLength, r.param
+		}
+	case "oneof":
+		for _, option := range strings.Fields(r.param) {
+			if v.String() == option {
+				return "", ""
+			}
+		}
+		return CodeOneOf, strings.Join(strings.Fields(r.param), ", ")
+	case "minage", "maxage":
+		t, ok := v.Interface().(time.Time)
+		if !ok {
+			return CodeInvalid, ""
+		}
+		limit, _ := strconv.Atoi(r.param)
+		age := yearsSince(t, time.Now().UTC())
+		if r.name == "minage" && age < limit {
+			return CodeMinAge, r.param
+		}
+		if r.name == "maxage" && age > limit {
+			return CodeMaxAge, r.param
+		}
+	}
+	return "", ""
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// yearsSince returns the number of full years between the date and now, the birthday is compared by month and
+// day because the day of the year shifts by one in leap years
+func yearsSince(t time.Time, now time.Time) int {
+	years := now.Year() - t.Year()
+	if now.Month() < t.Month() || (now.Month() == t.Month() && now.Day() < t.Day()) {
+		years--
+	}
+	return years
+}
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]field)
+	}
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous {
+			if ft := indirectType(sf.Type); ft.Kind() == reflect.Struct {
+				fields = append(fields, field{index: i, embedded: true})
+			}
+			continue
+		}
+		tag := sf.Tag.Get(tagName)
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+		f := field{index: i, name: fieldName(sf)}
+		for _, token := range strings.Split(tag, ",") {
+			name, param, _ := strings.Cut(strings.TrimSpace(token), "=")
+			switch name {
+			case "required":
+				f.required = true
+			case "omitempty":
+				f.omitempty = true
+			case "":
+			default:
+				f.rules = append(f.rules, rule{name: name, param: param})
+			}
+		}
+		fields = append(fields, f)
+	}
+	cache.Store(t, fields)
+	return fields
+}
+
+// fieldName is the name the client sent the field with
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "query", "form"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
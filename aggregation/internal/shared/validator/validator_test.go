@@ -0,0 +1,113 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestYearsSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		birthday time.Time
+		now      time.Time
+		want     int
+	}{
+		{"birthday today", date(2000, time.June, 15), date(2018, time.June, 15), 18},
+		{"day before birthday", date(2000, time.June, 15), date(2018, time.June, 14), 17},
+		{"born in a leap year, checked in a common year", date(2000, time.March, 1), date(2018, time.March, 1), 18},
+		{"born in a common year, checked in a leap year", date(2003, time.March, 1), date(2024, time.February, 29), 20},
+		{"born on february 29, checked on february 28", date(2000, time.February, 29), date(2018, time.February, 28), 17},
+		{"born on february 29, checked on march 1", date(2000, time.February, 29), date(2018, time.March, 1), 18},
+		{"end of the year", date(2000, time.December, 31), date(2018, time.December, 31), 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := yearsSince(tt.birthday, tt.now); got != tt.want {
+				t.Errorf("yearsSince(%v, %v) = %d, want %d", tt.birthday, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+type request struct {
+	Name     string    `json:"name" validate:"required,maxlen=5"`
+	Gender   string    `json:"gender" validate:"omitempty,oneof=man woman"`
+	Age      uint8     `json:"age" validate:"omitempty,min=18,max=100"`
+	Birthday time.Time `json:"birthday" validate:"omitempty,minage=18,maxage=100"`
+	Distance *float64  `json:"distance" validate:"max=1000"`
+	Pagination
+}
+
+type Pagination struct {
+	Size uint64 `query:"size" validate:"max=100"`
+}
+
+func TestStruct(t *testing.T) {
+	now := time.Now().UTC()
+	far := 1000.5
+	near := 10.0
+	tests := []struct {
+		name      string
+		req       request
+		wantField string
+		wantCode  string
+		wantParam string
+	}{
+		{"valid", request{Name: "Anna", Gender: "woman", Age: 18, Distance: &near}, "", "", ""},
+		{"required", request{}, "name", CodeRequired, ""},
+		{"maxlen counts characters", request{Name: "Анна"}, "", "", ""},
+		{"maxlen", request{Name: "Alexandra"}, "name", CodeMaxLength, "5"},
+		{"oneof", request{Name: "Anna", Gender: "cat"}, "gender", CodeOneOf, "man, woman"},
+		{"omitempty skips zero", request{Name: "Anna", Age: 0}, "", "", ""},
+		{"min", request{Name: "Anna", Age: 17}, "age", CodeMin, "18"},
+		{"max", request{Name: "Anna", Age: 101}, "age", CodeMax, "100"},
+		{"nil pointer is skipped", request{Name: "Anna", Distance: nil}, "", "", ""},
+		{"pointer is dereferenced", request{Name: "Anna", Distance: &far}, "distance", CodeMax, "1000"},
+		{"embedded struct", request{Name: "Anna", Pagination: Pagination{Size: 101}}, "size", CodeMax, "100"},
+		{"minage a day after", request{Name: "Anna", Birthday: now.AddDate(-18, 0, -1)}, "", "", ""},
+		{"minage a day before", request{Name: "Anna", Birthday: now.AddDate(-18, 0, 1)}, "birthday", CodeMinAge, "18"},
+		{"maxage", request{Name: "Anna", Birthday: now.AddDate(-101, 0, 0)}, "birthday", CodeMaxAge, "100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(&tt.req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("Struct() error = %v, want one field error", err)
+			}
+			fe := errs[0]
+			if fe.Field != tt.wantField || fe.Code != tt.wantCode || fe.Param != tt.wantParam {
+				t.Errorf("Struct() = %+v, want field %q code %q param %q", fe, tt.wantField, tt.wantCode, tt.wantParam)
+			}
+			if fe.Message == "" {
+				t.Errorf("Struct() message is empty")
+			}
+		})
+	}
+}
+
+func TestStruct_NotAStruct(t *testing.T) {
+	if err := Struct("name"); err == nil {
+		t.Error("Struct() error = nil, want an error for a string")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(messages[CodeRequired], ""); got != "is required" {
+		t.Errorf("Format() = %q", got)
+	}
+	if got := Format(messages[CodeMin], "18"); got != "must be at least 18" {
+		t.Errorf("Format() = %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/h2non/bimg"
+	"go.uber.org/zap"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// thumbnailWidth - the width of the image in the feed, the height keeps the aspect ratio
+const thumbnailWidth = 320
+
+// ImageStore keeps the profile images on the local disk converted to webp
+type ImageStore struct {
+	logger logger.Logger
+	root   string
+}
+
+func NewImageStore(l logger.Logger, root string) *ImageStore {
+	return &ImageStore{logger: l, root: root}
+}
+
+// Save converts the uploaded jpeg to webp and writes it with its thumbnail to the images directory of the owner
+func (s *ImageStore) Save(owner string, file *multipart.FileHeader) (*entity.ImageProfile, error) {
+	directoryPath := fmt.Sprintf("%s/%s/images", s.root, owner)
+	if err := os.MkdirAll(directoryPath, 0755); err != nil {
+		s.logger.Debug("error func Save, method MkdirAll by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	src, err := file.Open()
+	if err != nil {
+		s.logger.Debug("error func Save, method Open by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	defer src.Close()
+	buffer, err := io.ReadAll(src)
+	if err != nil {
+		s.logger.Debug("error func Save, method ReadAll by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	// only jpeg images are accepted
+	if _, err := jpeg.Decode(bytes.NewReader(buffer)); err != nil {
+		s.logger.Debug("error func Save, method jpeg.Decode by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	newImage, err := bimg.NewImage(buffer).Convert(bimg.WEBP)
+	if err != nil {
+		s.logger.Debug("error func Save, method Convert by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	thumbnail, err := bimg.NewImage(newImage).Process(bimg.Options{Width: thumbnailWidth, Type: bimg.WEBP})
+	if err != nil {
+		s.logger.Debug("error func Save, method Process by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	newFilePath := fmt.Sprintf("%s/%s", directoryPath, replaceExtension(filepath.Base(file.Filename)))
+	if err := bimg.Write(newFilePath, newImage); err != nil {
+		s.logger.Debug("error func Save, method Write by path internal/storage/files/image.go", zap.Error(err))
+		return nil, err
+	}
+	thumbnailPath := strings.TrimSuffix(newFilePath, ".webp") + "_thumb.webp"
+	if err := bimg.Write(thumbnailPath, thumbnail); err != nil {
+		s.logger.Debug("error func Save, method Write by path internal/storage/files/image.go", zap.Error(err))
+		_ = os.Remove(newFilePath)
+		return nil, err
+	}
+	return &entity.ImageProfile{
+		Name:         file.Filename,
+		Url:          newFilePath,
+		ThumbnailUrl: thumbnailPath,
+		Size:         file.Size,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		IsDeleted:    false,
+		IsBlocked:    false,
+		IsPrimary:    false,
+		IsPrivate:    false,
+	}, nil
+}
+
+func (s *ImageStore) Remove(url string) error {
+	if err := os.Remove(url); err != nil {
+		s.logger.Debug("error func Remove, method Remove by path internal/storage/files/image.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func replaceExtension(filename string) string {
+	// Удаляем текущее расширение
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	// Добавляем новое расширение .webp
+	return filename + ".webp"
+}
@@ -0,0 +1,344 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"go.uber.org/zap"
+	"time"
+)
+
+const defaultMessageListLimit = 50
+
+type ChatRepo struct {
+	logger logger.Logger
+	db     *sql.DB
+}
+
+func NewChatRepo(logger logger.Logger, db *sql.DB) usecases.ChatRepo {
+	return &ChatRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// CheckIfMessagingAllowed reports whether both profiles liked each other, neither of them has blocked
+// the other one and the match between them has not been removed
+func (r *ChatRepo) CheckIfMessagingAllowed(ctx context.Context, profileID uint64, participantID uint64) (bool, error) {
+	query := `SELECT
+			    EXISTS (SELECT 1 FROM profile_likes
+			            WHERE profile_id = $1 AND likedUser_id = $2 AND is_liked=true)
+			    AND EXISTS (SELECT 1 FROM profile_likes
+			                WHERE profile_id = $2 AND likedUser_id = $1 AND is_liked=true)
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks WHERE is_blocked=true AND
+			        ((profile_id = $1 AND blocked_user_id = $2) OR (profile_id = $2 AND blocked_user_id = $1)))
+			    AND NOT EXISTS (SELECT 1 FROM profile_matches WHERE is_deleted=true AND
+			        profile_id = LEAST($1::BIGINT, $2::BIGINT) AND matched_user_id = GREATEST($1::BIGINT, $2::BIGINT))`
+	var isAllowed bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, profileID, participantID).Scan(&isAllowed)
+	if err != nil {
+		r.logger.Debug("error func CheckIfMessagingAllowed, method QueryRowContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return false, err
+	}
+	return isAllowed, nil
+}
+
+// AddConversation returns the conversation of the pair, creating it on the first message.
+// An existing conversation is touched so that it moves to the top of the list.
+func (r *ChatRepo) AddConversation(ctx context.Context, c *entity.Conversation) (*entity.Conversation, error) {
+	if c.ProfileID > c.ParticipantID {
+		c.ProfileID, c.ParticipantID = c.ParticipantID, c.ProfileID
+	}
+	query := `INSERT INTO conversations (profile_id, participant_id, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (profile_id, participant_id) DO UPDATE SET updated_at = EXCLUDED.updated_at
+			  RETURNING id, created_at`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &c.ProfileID, &c.ParticipantID, &c.CreatedAt, &c.UpdatedAt).
+		Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		r.logger.Debug("error func AddConversation, method QueryRowContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return c, nil
+}
+
+func (r *ChatRepo) FindConversationByID(ctx context.Context, id uint64) (*entity.Conversation, bool, error) {
+	c := entity.Conversation{}
+	query := `SELECT id, profile_id, participant_id, created_at, updated_at
+			  FROM conversations
+			  WHERE id=$1`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
+		Scan(&c.ID, &c.ProfileID, &c.ParticipantID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		r.logger.Debug("error func FindConversationByID, method Scan by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, false, translate(err)
+	}
+	return &c, true, nil
+}
+
+func (r *ChatRepo) SelectConversationList(ctx context.Context, profileID uint64,
+	qp *entity.QueryParamsConversationList) (*entity.ResponseListConversation, error) {
+	query := `SELECT c.id, p.id, p.display_name, p.last_online, c.updated_at,
+			    (SELECT pi.url FROM profile_images pi
+			     WHERE pi.profile_id = p.id AND pi.is_deleted=false AND pi.is_blocked=false AND pi.is_private=false
+			     ORDER BY pi.is_primary DESC, pi.id ASC LIMIT 1) as url,
+			    (SELECT COUNT(*) FROM messages um
+			     WHERE um.conversation_id = c.id AND um.receiver_id = $1 AND um.read_at IS NULL
+			       AND um.is_deleted=false) as unread_count,
+			    lm.id, lm.sender_id, lm.receiver_id, lm.message, lm.is_edited, lm.read_at, lm.created_at,
+			    lm.updated_at
+			  FROM conversations c
+			  JOIN profiles p
+			    ON p.id = CASE WHEN c.profile_id = $1 THEN c.participant_id ELSE c.profile_id END
+			  LEFT JOIN LATERAL (SELECT id, sender_id, receiver_id, message, is_edited, read_at, created_at, updated_at
+			                     FROM messages
+			                     WHERE conversation_id = c.id AND is_deleted=false
+			                     ORDER BY id DESC LIMIT 1) lm ON true
+			  WHERE (c.profile_id = $1 OR c.participant_id = $1) AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			  ORDER BY c.updated_at DESC, c.id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM conversations c
+			  JOIN profiles p
+			    ON p.id = CASE WHEN c.profile_id = $1 THEN c.participant_id ELSE c.profile_id END
+			  WHERE (c.profile_id = $1 OR c.participant_id = $1) AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)`
+	size := qp.Size
+	page := qp.Page
+	// get totalItems
+	totalItems, err := entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, profileID)
+	if err != nil {
+		r.logger.Debug("error func SelectConversationList, method GetTotalItems by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	// pagination
+	query = entity.ApplyPagination(query, page, size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
+	if err != nil {
+		r.logger.Debug("error func SelectConversationList, method QueryContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.ContentListConversation, 0)
+	for rows.Next() {
+		c := entity.ContentListConversation{}
+		var url sql.NullString
+		var messageID, senderID, receiverID sql.NullInt64
+		var message sql.NullString
+		var isEdited sql.NullBool
+		var readAt, createdAt, updatedAt sql.NullTime
+		err := rows.Scan(&c.ID, &c.ProfileID, &c.DisplayName, &c.LastOnline, &c.UpdatedAt, &url,
+			&c.UnreadCount, &messageID, &senderID, &receiverID, &message, &isEdited, &readAt, &createdAt,
+			&updatedAt)
+		if err != nil {
+			r.logger.Debug("error func SelectConversationList, method Scan by path"+
+				" internal/storage/psql/chat/chat.go", zap.Error(err))
+			return nil, err
+		}
+		elapsed := time.Since(c.LastOnline)
+		if elapsed.Minutes() < 5 {
+			c.IsOnline = true
+		}
+		if url.Valid {
+			c.Image = &entity.ResponseImageProfile{
+				Url: url.String,
+			}
+		}
+		if messageID.Valid {
+			m := entity.Message{
+				ID:             uint64(messageID.Int64),
+				ConversationID: c.ID,
+				SenderID:       uint64(senderID.Int64),
+				ReceiverID:     uint64(receiverID.Int64),
+				Message:        message.String,
+				IsEdited:       isEdited.Bool,
+				IsRead:         readAt.Valid,
+				CreatedAt:      createdAt.Time,
+				UpdatedAt:      updatedAt.Time,
+			}
+			if readAt.Valid {
+				m.ReadAt = &readAt.Time
+			}
+			c.LastMessage = &m
+		}
+		list = append(list, &c)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectConversationList, method Err by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	paging := entity.GetPagination(size, page, totalItems)
+	response := entity.ResponseListConversation{
+		Pagination: paging,
+		Content:    list,
+	}
+	return &response, nil
+}
+
+func (r *ChatRepo) AddMessage(ctx context.Context, m *entity.Message) (*entity.Message, error) {
+	query := `INSERT INTO messages (conversation_id, sender_id, receiver_id, message, is_edited, is_deleted,
+			  created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  RETURNING id`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &m.ConversationID, &m.SenderID, &m.ReceiverID, &m.Message,
+		&m.IsEdited, &m.IsDeleted, &m.CreatedAt, &m.UpdatedAt).Scan(&m.ID)
+	if err != nil {
+		r.logger.Debug("error func AddMessage, method QueryRowContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return m, nil
+}
+
+func (r *ChatRepo) UpdateMessage(ctx context.Context, m *entity.Message) (*entity.Message, error) {
+	query := `UPDATE messages SET message=$1, is_edited=$2, updated_at=$3 WHERE id=$4`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &m.Message, &m.IsEdited, &m.UpdatedAt, &m.ID)
+	if err != nil {
+		r.logger.Debug("error func UpdateMessage, method ExecContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return r.findMessageByID(ctx, m.ID)
+}
+
+func (r *ChatRepo) DeleteMessage(ctx context.Context, m *entity.Message) (*entity.Message, error) {
+	query := `UPDATE messages SET is_deleted=$1, updated_at=$2 WHERE id=$3`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &m.IsDeleted, &m.UpdatedAt, &m.ID)
+	if err != nil {
+		r.logger.Debug("error func DeleteMessage, method ExecContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return r.findMessageByID(ctx, m.ID)
+}
+
+func (r *ChatRepo) FindMessageByID(ctx context.Context, id uint64) (*entity.Message, bool, error) {
+	m, err := r.findMessageByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, usecases.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return m, true, nil
+}
+
+func (r *ChatRepo) findMessageByID(ctx context.Context, id uint64) (*entity.Message, error) {
+	m := entity.Message{}
+	var readAt sql.NullTime
+	query := `SELECT id, conversation_id, sender_id, receiver_id, message, is_edited, is_deleted, read_at,
+			  created_at, updated_at
+			  FROM messages
+			  WHERE id=$1`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.ReceiverID,
+		&m.Message, &m.IsEdited, &m.IsDeleted, &readAt, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Debug("error func findMessageByID, method Scan by path"+
+				" internal/storage/psql/chat/chat.go", zap.Error(err))
+		}
+		return nil, translate(err)
+	}
+	if readAt.Valid {
+		m.IsRead = true
+		m.ReadAt = &readAt.Time
+	}
+	return &m, nil
+}
+
+// SelectMessageList returns messages of the conversation from the newest to the oldest.
+// The cursor is the id of the last message of the previous page, zero means the first page.
+func (r *ChatRepo) SelectMessageList(
+	ctx context.Context, qp *entity.QueryParamsMessageList) (*entity.ResponseListMessage, error) {
+	limit := qp.Limit
+	if limit == 0 || limit > defaultMessageListLimit {
+		limit = defaultMessageListLimit
+	}
+	query := `SELECT id, conversation_id, sender_id, receiver_id, message, is_edited, is_deleted, read_at,
+			  created_at, updated_at
+			  FROM messages
+			  WHERE conversation_id=$1 AND is_deleted=false AND ($2::BIGINT = 0 OR id < $2::BIGINT)
+			  ORDER BY id DESC
+			  LIMIT $3`
+	// one extra row tells whether there is a next page
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, qp.ConversationID, qp.Cursor, limit+1)
+	if err != nil {
+		r.logger.Debug("error func SelectMessageList, method QueryContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.Message, 0, limit)
+	for rows.Next() {
+		m := entity.Message{}
+		var readAt sql.NullTime
+		err := rows.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.ReceiverID, &m.Message, &m.IsEdited,
+			&m.IsDeleted, &readAt, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			r.logger.Debug("error func SelectMessageList, method Scan by path"+
+				" internal/storage/psql/chat/chat.go", zap.Error(err))
+			return nil, err
+		}
+		if readAt.Valid {
+			m.IsRead = true
+			m.ReadAt = &readAt.Time
+		}
+		list = append(list, &m)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectMessageList, method Err by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	response := entity.ResponseListMessage{
+		HasNext: uint64(len(list)) > limit,
+		Content: list,
+	}
+	if response.HasNext {
+		response.Content = list[:limit]
+		response.Cursor = response.Content[limit-1].ID
+	}
+	return &response, nil
+}
+
+// ReadMessages marks messages addressed to the receiver up to and including messageID as read
+func (r *ChatRepo) ReadMessages(ctx context.Context, conversationID uint64, receiverID uint64,
+	messageID uint64, readAt time.Time) error {
+	query := `UPDATE messages SET read_at=$1
+			  WHERE conversation_id=$2 AND receiver_id=$3 AND id<=$4 AND read_at IS NULL`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, readAt, conversationID, receiverID, messageID)
+	if err != nil {
+		r.logger.Debug("error func ReadMessages, method ExecContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ChatRepo) CountUnreadMessages(ctx context.Context, conversationID uint64, receiverID uint64) (uint64, error) {
+	query := `SELECT COUNT(*)
+			  FROM messages
+			  WHERE conversation_id=$1 AND receiver_id=$2 AND read_at IS NULL AND is_deleted=false`
+	var totalItems uint64
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, conversationID, receiverID).Scan(&totalItems)
+	if err != nil {
+		r.logger.Debug("error func CountUnreadMessages, method QueryRowContext by path"+
+			" internal/storage/psql/chat/chat.go", zap.Error(err))
+		return 0, err
+	}
+	return totalItems, nil
+}
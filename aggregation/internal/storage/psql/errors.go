@@ -0,0 +1,26 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation    = "23505"
+	exclusionViolation = "23P01"
+)
+
+// translate wraps the driver error into the use case sentinel, the original error stays in the chain
+func translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", usecases.ErrNotFound, err)
+	}
+	var pqError *pq.Error
+	if errors.As(err, &pqError) && (pqError.Code == uniqueViolation || pqError.Code == exclusionViolation) {
+		return fmt.Errorf("%w: %w", usecases.ErrConflict, err)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	driverError := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no rows", sql.ErrNoRows, usecases.ErrNotFound},
+		{"unique violation", &pq.Error{Code: uniqueViolation}, usecases.ErrConflict},
+		{"exclusion violation", &pq.Error{Code: exclusionViolation}, usecases.ErrConflict},
+		{"foreign key violation", &pq.Error{Code: "23503"}, nil},
+		{"other error", driverError, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translate(tt.err)
+			if !errors.Is(got, tt.err) {
+				t.Errorf("translate(%v) = %v, the original error is lost", tt.err, got)
+			}
+			if tt.want != nil && !errors.Is(got, tt.want) {
+				t.Errorf("translate(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+			if tt.want == nil && (errors.Is(got, usecases.ErrNotFound) || errors.Is(got, usecases.ErrConflict)) {
+				t.Errorf("translate(%v) = %v, want the error untouched", tt.err, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"go.uber.org/zap"
+	"time"
+)
+
+const outboxColumns = `id, profile_id, type, payload, status, attempts, last_error, next_attempt_at, sent_at,
+			  created_at, updated_at`
+
+type OutboxRepo struct {
+	logger logger.Logger
+	db     *sql.DB
+}
+
+func NewOutboxRepo(logger logger.Logger, db *sql.DB) usecases.OutboxRepo {
+	return &OutboxRepo{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (r *OutboxRepo) Add(ctx context.Context, o *entity.NotificationOutbox) (*entity.NotificationOutbox, error) {
+	payload, err := json.Marshal(o.Payload)
+	if err != nil {
+		r.logger.Debug("error func Add, method Marshal by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	query := `INSERT INTO notification_outbox (profile_id, type, payload, status, attempts, next_attempt_at,
+			  created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  RETURNING id`
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, &o.ProfileID, &o.Type, payload, &o.Status, &o.Attempts,
+		&o.NextAttemptAt, &o.CreatedAt, &o.UpdatedAt).Scan(&o.ID)
+	if err != nil {
+		r.logger.Debug("error func Add, method QueryRowContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	return o, nil
+}
+
+// ClaimPending leases due notifications to the dispatcher until leaseUntil: their next attempt is moved there,
+// so other dispatchers skip them while they are sent. The rows of a dispatcher that stopped before marking them
+// become due again when the lease expires. Rows locked by another dispatcher are skipped.
+func (r *OutboxRepo) ClaimPending(
+	ctx context.Context, limit uint64, leaseUntil time.Time) ([]*entity.NotificationOutbox, error) {
+	query := `UPDATE notification_outbox
+			  SET next_attempt_at=$3, updated_at=$2
+			  WHERE id IN (
+			      SELECT id
+			      FROM notification_outbox
+			      WHERE status = $1 AND next_attempt_at <= $2
+			      ORDER BY next_attempt_at, id
+			      LIMIT $4
+			      FOR UPDATE SKIP LOCKED)
+			  RETURNING ` + outboxColumns
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, entity.OutboxStatusPending, time.Now().UTC(), leaseUntil,
+		limit)
+	if err != nil {
+		r.logger.Debug("error func ClaimPending, method QueryContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	return r.scanList(rows)
+}
+
+func (r *OutboxRepo) MarkSent(ctx context.Context, id uint64, sentAt time.Time) error {
+	query := `UPDATE notification_outbox
+			  SET status=$1, attempts=attempts+1, last_error=NULL, sent_at=$2, updated_at=$2
+			  WHERE id=$3`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, entity.OutboxStatusSent, sentAt, id)
+	if err != nil {
+		r.logger.Debug("error func MarkSent, method ExecContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, o *entity.NotificationOutbox) error {
+	query := `UPDATE notification_outbox
+			  SET status=$1, attempts=$2, last_error=$3, next_attempt_at=$4, updated_at=$5
+			  WHERE id=$6`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &o.Status, &o.Attempts, &o.LastError, &o.NextAttemptAt,
+		&o.UpdatedAt, &o.ID)
+	if err != nil {
+		r.logger.Debug("error func MarkFailed, method ExecContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Replay puts a dead notification back to the queue, it reports false if there is no such dead notification
+func (r *OutboxRepo) Replay(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error) {
+	query := `UPDATE notification_outbox
+			  SET status=$1, attempts=0, last_error=NULL, next_attempt_at=$2, updated_at=$2
+			  WHERE id=$3 AND status=$4`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, entity.OutboxStatusPending, time.Now().UTC(), id,
+		entity.OutboxStatusDead)
+	if err != nil {
+		r.logger.Debug("error func Replay, method ExecContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Debug("error func Replay, method RowsAffected by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, false, err
+	}
+	if count == 0 {
+		return nil, false, nil
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *OutboxRepo) FindByID(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error) {
+	query := `SELECT ` + outboxColumns + `
+			  FROM notification_outbox
+			  WHERE id=$1`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, id)
+	if err != nil {
+		r.logger.Debug("error func FindByID, method QueryContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, false, err
+	}
+	defer rows.Close()
+	list, err := r.scanList(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(list) == 0 {
+		return nil, false, nil
+	}
+	return list[0], true, nil
+}
+
+func (r *OutboxRepo) SelectList(
+	ctx context.Context, qp *entity.QueryParamsOutboxList) (*entity.ResponseListOutbox, error) {
+	query := `SELECT ` + outboxColumns + `
+			  FROM notification_outbox
+			  WHERE ($1 = '' OR status = $1)
+			  ORDER BY id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM notification_outbox
+			  WHERE ($1 = '' OR status = $1)`
+	size := qp.Size
+	page := qp.Page
+	// get totalItems
+	totalItems, err := entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, qp.Status)
+	if err != nil {
+		r.logger.Debug("error func SelectList, method GetTotalItems by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	// pagination
+	query = entity.ApplyPagination(query, page, size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, qp.Status)
+	if err != nil {
+		r.logger.Debug("error func SelectList, method QueryContext by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list, err := r.scanList(rows)
+	if err != nil {
+		return nil, err
+	}
+	paging := entity.GetPagination(size, page, totalItems)
+	response := entity.ResponseListOutbox{
+		Pagination: paging,
+		Content:    list,
+	}
+	return &response, nil
+}
+
+func (r *OutboxRepo) scanList(rows *sql.Rows) ([]*entity.NotificationOutbox, error) {
+	list := make([]*entity.NotificationOutbox, 0)
+	for rows.Next() {
+		o := entity.NotificationOutbox{}
+		var payload []byte
+		var lastError sql.NullString
+		var sentAt, updatedAt sql.NullTime
+		err := rows.Scan(&o.ID, &o.ProfileID, &o.Type, &payload, &o.Status, &o.Attempts, &lastError,
+			&o.NextAttemptAt, &sentAt, &o.CreatedAt, &updatedAt)
+		if err != nil {
+			r.logger.Debug("error func scanList, method Scan by path"+
+				" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &o.Payload); err != nil {
+			r.logger.Debug("error func scanList, method Unmarshal by path"+
+				" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+			return nil, err
+		}
+		o.LastError = lastError.String
+		o.UpdatedAt = updatedAt.Time
+		if sentAt.Valid {
+			o.SentAt = &sentAt.Time
+		}
+		list = append(list, &o)
+	}
+	if err := rows.Err(); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.logger.Debug("error func scanList, method Err by path"+
+			" internal/storage/psql/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	return list, nil
+}
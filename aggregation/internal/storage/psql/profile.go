@@ -9,7 +9,7 @@ import (
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
 	"go.uber.org/zap"
 	"math"
-	"strconv"
+	"strings"
 	"time"
 )
 
@@ -29,128 +29,92 @@ func (r *ProfileRepo) Add(ctx context.Context, p *entity.Profile) (*entity.Profi
 	birthday := p.Birthday.Format("2006-01-02")
 	query := "INSERT INTO profiles (session_id, display_name, birthday, gender, location, description," +
 		" height, weight, is_deleted, is_blocked, is_premium, is_show_distance, is_invisible," +
-		" created_at, updated_at, last_online) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14," +
-		" $15, $16) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.SessionID, &p.DisplayName, &birthday, &p.Gender, &p.Location,
+		" created_at, updated_at, last_online, looking_for) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12," +
+		" $13, $14, $15, $16, $17) RETURNING id"
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.SessionID, &p.DisplayName, &birthday, &p.Gender, &p.Location,
 		&p.Description, &p.Height, &p.Weight, p.IsDeleted, &p.IsBlocked, &p.IsPremium, &p.IsShowDistance,
-		&p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline).Scan(&p.ID)
+		&p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline, &p.LookingFor).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func Add, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) Update(ctx context.Context, p *entity.Profile) (*entity.Profile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug(
-			"error func Update, method Begin by path internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profiles SET display_name=$1, birthday=$2, gender=$3, location=$4," +
 		" description=$5, height=$6, weight=$7, is_blocked=$8, is_premium=$9, is_show_distance=$10," +
-		" is_invisible=$11, updated_at=$12, last_online=$13 WHERE id=$14"
-	_, err = r.db.ExecContext(ctx, query, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
+		" is_invisible=$11, updated_at=$12, last_online=$13, looking_for=$14 WHERE id=$15"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
 		&p.Description, &p.Height, &p.Weight, &p.IsBlocked, &p.IsPremium, &p.IsShowDistance,
-		&p.IsInvisible, &p.UpdatedAt, &p.LastOnline, &p.ID)
+		&p.IsInvisible, &p.UpdatedAt, &p.LastOnline, &p.LookingFor, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func Update, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateLastOnline(ctx context.Context, profileID uint64) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateLastOnline, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profiles SET last_online=$1 WHERE id=$2"
-	_, err = r.db.ExecContext(ctx, query, time.Now().UTC(), profileID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, time.Now().UTC(), profileID)
 	if err != nil {
 		r.logger.Debug("error func UpdateLastOnline, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return err
+		return translate(err)
 	}
-	tx.Commit()
 	return nil
 }
 
 func (r *ProfileRepo) Delete(ctx context.Context, p *entity.Profile) (*entity.Profile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug(
-			"error func Delete, method Begin by path internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profiles SET session_id=$1, display_name=$2, birthday=$3, gender=$4, location=$5," +
 		" description=$6, height=$7, weight=$8, is_deleted=$9, is_blocked=$10, is_premium=$11," +
 		" is_show_distance=$12, is_invisible=$13, updated_at=$14, last_online=$15 WHERE id=$16"
-	_, err = r.db.ExecContext(ctx, query, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
 		&p.Description, &p.Height, &p.Weight, &p.IsDeleted, &p.IsBlocked, &p.IsPremium, &p.IsShowDistance,
 		&p.IsInvisible, &p.UpdatedAt, &p.LastOnline, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func Delete, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) FindById(ctx context.Context, id uint64) (*entity.Profile, error) {
 	p := entity.Profile{}
-	query := `SELECT id, session_id, display_name, birthday, gender, location, description, height, weight,
+	query := `SELECT id, session_id, display_name, birthday, gender, location, description, height, weight, looking_for,
        is_deleted, is_blocked, is_premium, is_show_distance, is_invisible, created_at, updated_at, last_online
 			  FROM profiles
 			  WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindById, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
 	err := row.Scan(&p.ID, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
-		&p.Description, &p.Height, &p.Weight, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
+		&p.Description, &p.Height, &p.Weight, &p.LookingFor, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
 		&p.IsShowDistance, &p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline)
 	if err != nil {
 		r.logger.Debug("error func FindById, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
 
 func (r *ProfileRepo) FindBySessionID(ctx context.Context, sessionID string) (*entity.Profile, error) {
 	p := entity.Profile{}
-	query := `SELECT id, session_id, display_name, birthday, gender, location, description, height, weight,
+	query := `SELECT id, session_id, display_name, birthday, gender, location, description, height, weight, looking_for,
        is_deleted, is_blocked, is_premium, is_show_distance, is_invisible, created_at, updated_at, last_online
 			  FROM profiles
 			  WHERE session_id=$1`
-	row := r.db.QueryRowContext(ctx, query, sessionID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindBySessionID, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, sessionID)
 	err := row.Scan(&p.ID, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
-		&p.Description, &p.Height, &p.Weight, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
+		&p.Description, &p.Height, &p.Weight, &p.LookingFor, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
 		&p.IsShowDistance, &p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline)
 	if err != nil {
 		r.logger.Debug("error func FindBySessionID, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
@@ -158,90 +122,149 @@ func (r *ProfileRepo) FindBySessionID(ctx context.Context, sessionID string) (*e
 func (r *ProfileRepo) FindByTelegramId(ctx context.Context, telegramID uint64) (*entity.Profile, error) {
 	p := entity.Profile{}
 	query := `SELECT p.id, p.session_id, p.display_name, p.birthday, p.gender, p.location,
-       p.description, p.height, p.weight, p.is_deleted, p.is_blocked, p.is_premium, p.is_show_distance,
+       p.description, p.height, p.weight, p.looking_for, p.is_deleted, p.is_blocked, p.is_premium, p.is_show_distance,
        p.is_invisible, p.created_at, p.updated_at,  p.last_online
 			  FROM profiles p
-			  JOIN profile_telegram pt ON p.id = pt.profile_id
-			  WHERE pt.telegram_id = $1`
-	row := r.db.QueryRowContext(ctx, query, telegramID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindByTelegramId, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+			  JOIN profile_identities pi ON p.id = pi.profile_id
+			  WHERE pi.telegram_id = $1`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, telegramID)
 	err := row.Scan(&p.ID, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
-		&p.Description, &p.Height, &p.Weight, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
+		&p.Description, &p.Height, &p.Weight, &p.LookingFor, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
 		&p.IsShowDistance, &p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline)
 	if err != nil {
 		r.logger.Debug("error func FindByTelegramId, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
 
-func (r *ProfileRepo) SelectList(
-	ctx context.Context, qp *entity.QueryParamsProfileList) (*entity.ResponseListProfile, error) {
-	p, err := r.FindBySessionID(ctx, qp.SessionID)
+func (r *ProfileRepo) FindByKeycloakUserID(ctx context.Context, keycloakUserID string) (*entity.Profile, error) {
+	p := entity.Profile{}
+	query := `SELECT p.id, p.session_id, p.display_name, p.birthday, p.gender, p.location,
+       p.description, p.height, p.weight, p.looking_for, p.is_deleted, p.is_blocked, p.is_premium, p.is_show_distance,
+       p.is_invisible, p.created_at, p.updated_at,  p.last_online
+			  FROM profiles p
+			  JOIN profile_identities pi ON p.id = pi.profile_id
+			  WHERE pi.keycloak_user_id = $1`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, keycloakUserID).Scan(&p.ID, &p.SessionID, &p.DisplayName,
+		&p.Birthday, &p.Gender, &p.Location, &p.Description, &p.Height, &p.Weight, &p.LookingFor, &p.IsDeleted, &p.IsBlocked,
+		&p.IsPremium, &p.IsShowDistance, &p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline)
 	if err != nil {
-		r.logger.Debug("error func SelectList, method FindBySessionID by path"+
+		r.logger.Debug("error func FindByKeycloakUserID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	ageFromInt, err := strconv.Atoi(qp.AgeFrom)
-	if err != nil {
+	return &p, nil
+}
+
+// listSelect - the columns of a profile in the discovery lists, they are read by scanListProfile
+const listSelect = "SELECT p.id, p.last_online, ST_Distance(pn.geog, v.geog) AS distance," +
+	" pi.url, pi.thumbnail_url"
+
+// listImageJoin picks the primary image in the same query, the oldest public image when there is no primary one
+const listImageJoin = " LEFT JOIN LATERAL (SELECT url, thumbnail_url FROM profile_images" +
+	" WHERE profile_id = p.id AND is_deleted=false AND is_blocked=false AND is_private=false" +
+	" ORDER BY is_primary DESC, id ASC LIMIT 1) pi ON true"
+
+// listFilter returns the joins, the conditions and their parameters $1-$11 of the profiles the viewer searches for,
+// the viewer id is $4
+func listFilter(p *entity.Profile, qp *entity.QueryParamsProfileList) (string, string, []interface{}) {
+	birthYearStart := time.Now().UTC().Year() - int(qp.AgeTo) - 1
+	birthYearEnd := time.Now().UTC().Year() - int(qp.AgeFrom)
+	birthdateFrom := time.Date(birthYearStart, time.January, 1, 0, 0, 0, 0, time.UTC)
+	birthdateTo := time.Date(birthYearEnd, time.December, 31, 23, 59, 59, 999999999, time.UTC)
+	distanceMeters := float64(qp.Distance) * 1000 // Convert kilometers to meters
+	// the viewer point is taken once, ST_DWithin on the geography column is served by its GiST index
+	from := " FROM profiles p" +
+		" JOIN profile_navigators pn ON pn.profile_id = p.id" +
+		" CROSS JOIN (SELECT geog FROM profile_navigators WHERE profile_id = $4 AND geog IS NOT NULL LIMIT 1) v"
+	where := " WHERE p.is_deleted=false AND p.is_blocked=false AND p.birthday BETWEEN $1 AND $2" +
+		" AND ($3 = 'all' OR p.gender=$3) AND p.id <> $4 AND" +
+		" ST_DWithin(pn.geog, v.geog, $5) AND" +
+		" NOT EXISTS (SELECT 1 FROM profile_blocks WHERE profile_id = $4 AND blocked_user_id = p.id) AND" +
+		" NOT EXISTS (SELECT 1 FROM profile_matches WHERE is_deleted=true AND" +
+		" ((profile_id = $4 AND matched_user_id = p.id) OR (profile_id = p.id AND matched_user_id = $4))) AND" +
+		// both sides must be looking for the same thing, 'all' matches anything
+		" ($6 IN ('', 'all') OR p.looking_for IN ($6, 'all')) AND" +
+		" ($7 = 'all' OR NOT EXISTS (SELECT 1 FROM profile_filters WHERE profile_id = p.id AND" +
+		" COALESCE(looking_for, '') NOT IN ('', 'all', $7))) AND" +
+		" ($8 = 0 OR p.height >= $8) AND ($9 = 0 OR p.height <= $9) AND" +
+		" ($10 = 0 OR p.weight >= $10) AND ($11 = 0 OR p.weight <= $11)"
+	queryParams := []interface{}{birthdateFrom, birthdateTo, qp.SearchGender, p.ID, distanceMeters, qp.LookingFor,
+		p.LookingFor, qp.HeightFrom, qp.HeightTo, qp.WeightFrom, qp.WeightTo}
+	return from, where, queryParams
+}
+
+// scanListProfile reads the row of listSelect
+func scanListProfile(rows *sql.Rows) (*entity.ContentListProfile, error) {
+	lp := entity.ContentListProfile{Navigator: &entity.ResponseNavigatorProfile{}}
+	var url, thumbnailUrl sql.NullString
+	if err := rows.Scan(&lp.ID, &lp.LastOnline, &lp.Navigator.Distance, &url, &thumbnailUrl); err != nil {
 		return nil, err
 	}
-	ageToInt, err := strconv.Atoi(qp.AgeTo)
-	if err != nil {
-		return nil, err
+	elapsed := time.Since(lp.LastOnline)
+	if elapsed.Minutes() < 5 {
+		lp.IsOnline = true
 	}
-	birthYearStart := time.Now().UTC().Year() - ageToInt - 1
-	birthYearEnd := time.Now().UTC().Year() - ageFromInt
-	birthdateFrom := time.Date(birthYearStart, time.January, 1, 0, 0, 0, 0, time.UTC)
-	birthdateTo := time.Date(birthYearEnd, time.December, 31, 23, 59, 59, 999999999, time.UTC)
-	// Convert qp.Distance from kilometers to meters
-	distanceMeters, err := strconv.ParseFloat(qp.Distance, 64)
+	lp.Image = listImage(url, thumbnailUrl)
+	return &lp, nil
+}
+
+// listImage returns the image of listImageJoin, nil when the profile has no public images
+func listImage(url, thumbnailUrl sql.NullString) *entity.ResponseImageProfile {
+	if !url.Valid {
+		return nil
+	}
+	i := entity.ResponseImageProfile{
+		Url:          url.String,
+		ThumbnailUrl: thumbnailUrl.String,
+	}
+	if i.ThumbnailUrl == "" {
+		i.ThumbnailUrl = i.Url
+	}
+	return &i
+}
+
+func (r *ProfileRepo) SelectList(
+	ctx context.Context, qp *entity.QueryParamsProfileList) (*entity.ResponseListProfile, error) {
+	p, err := r.FindBySessionID(ctx, qp.SessionID)
 	if err != nil {
-		r.logger.Debug("error func SelectList, method ParseFloat by path"+
+		r.logger.Debug("error func SelectList, method FindBySessionID by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
 		return nil, err
 	}
-	distanceMeters *= 1000 // Convert kilometers to meters
-	query := "SELECT p.id, p.session_id, p.display_name, p.birthday, p.gender, p.location," +
-		" p.description, p.height, p.weight, p.is_deleted, p.is_blocked, p.is_premium," +
-		" p.is_show_distance, p.is_invisible, p.created_at, p.updated_at, p.last_online," +
-		" ST_Distance((SELECT location FROM profile_navigators WHERE profile_id = p.id)::geography, " +
-		" ST_SetSRID(ST_MakePoint((SELECT ST_X(location) FROM profile_navigators WHERE profile_id = $4), " +
-		" (SELECT ST_Y(location) FROM profile_navigators WHERE profile_id = $4)),  4326)::geography) as distance" +
-		" FROM profiles p" +
-		" JOIN profile_navigators pn ON p.id = pn.profile_id" +
-		" WHERE p.is_deleted=false AND  p.is_blocked=false AND  p.birthday BETWEEN $1 AND $2" +
-		" AND ($3 = 'all' OR gender=$3) AND  p.id <> $4 AND" +
-		" NOT EXISTS (SELECT 1 FROM profile_blocks WHERE profile_id = $4 AND blocked_user_id = p.id) AND" +
-		" ST_Distance((SELECT location FROM profile_navigators WHERE profile_id = p.id)::geography, " +
-		" ST_SetSRID(ST_MakePoint((SELECT ST_X(location) FROM profile_navigators WHERE profile_id = $4), " +
-		" (SELECT ST_Y(location) FROM profile_navigators WHERE profile_id = $4)), 4326)::geography) <= $5" +
-		" ORDER BY distance ASC, p.last_online DESC"
-	countQuery := "SELECT COUNT(*) FROM profiles WHERE is_deleted=false AND is_blocked=false AND birthday BETWEEN $1" +
-		" AND $2 AND ($3 = 'all' OR gender=$3) AND id <> $4"
+	from, where, queryParams := listFilter(p, qp)
+	query := listSelect + from + listImageJoin + where
+	countQuery := "SELECT COUNT(*)" + from + where
 	size := qp.Size
 	page := qp.Page
-	// get totalItems
-	totalItems, err := entity.GetTotalItems(ctx, r.db, countQuery, birthdateFrom, birthdateTo, qp.SearchGender,
-		p.ID)
-	if err != nil {
-		r.logger.Debug("error func SelectList, method GetTotalItems by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+	var totalItems uint64
+	if qp.Cursor != "" {
+		c, err := entity.DecodeCursor(qp.Cursor)
+		if err != nil {
+			r.logger.Debug("error func SelectList, method DecodeCursor by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		// the rows after the cursor in the order of the list
+		query += " AND (ST_Distance(pn.geog, v.geog) > $12 OR (ST_Distance(pn.geog, v.geog) = $12 AND" +
+			" (p.last_online < $13 OR (p.last_online = $13 AND p.id > $14))))" +
+			" ORDER BY distance ASC, p.last_online DESC, p.id ASC"
+		query = entity.ApplyLimit(query, size)
+		queryParams = append(queryParams, c.Distance, c.LastOnline, c.ID)
+	} else {
+		// the count has the same filters as the page, so TotalItems and HasNext match the result set
+		totalItems, err = entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, queryParams...)
+		if err != nil {
+			r.logger.Debug("error func SelectList, method GetTotalItems by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		query += " ORDER BY distance ASC, p.last_online DESC, p.id ASC"
+		query = entity.ApplyPagination(query, page, size)
 	}
-	// pagination
-	query = entity.ApplyPagination(query, page, size)
-	countQuery = entity.ApplyPagination(countQuery, page, size)
-	// get navigator by profile id
-	queryParams := []interface{}{birthdateFrom, birthdateTo, qp.SearchGender, p.ID, distanceMeters}
-	rows, err := r.db.QueryContext(ctx, query, queryParams...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		r.logger.Debug("error func SelectList, method QueryContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -249,43 +272,36 @@ func (r *ProfileRepo) SelectList(
 	}
 	defer rows.Close()
 	list := make([]*entity.ContentListProfile, 0)
+	var last entity.Cursor
 	for rows.Next() {
-		p := entity.Profile{}
-		n := &entity.ResponseNavigatorProfile{}
-		err := rows.Scan(&p.ID, &p.SessionID, &p.DisplayName, &p.Birthday, &p.Gender, &p.Location,
-			&p.Description, &p.Height, &p.Weight, &p.IsDeleted, &p.IsBlocked, &p.IsPremium,
-			&p.IsShowDistance, &p.IsInvisible, &p.CreatedAt, &p.UpdatedAt, &p.LastOnline, &n.Distance)
+		lp, err := scanListProfile(rows)
 		if err != nil {
 			r.logger.Debug("error func SelectList, method Scan by path internal/storage/psql/profile/profile.go",
 				zap.Error(err))
-			continue
+			return nil, err
 		}
-		images, err := r.SelectListPublicImage(ctx, p.ID)
-		if err != nil {
-			r.logger.Debug("error func SelectList, method SelectListPublicImage by path"+
-				" internal/storage/psql/profile/profile.go", zap.Error(err))
-			continue
-		}
-		lp := entity.ContentListProfile{
-			ID:         p.ID,
-			IsOnline:   false,
-			LastOnline: p.LastOnline,
-			Image:      nil,
-			Navigator:  n,
+		if uint64(len(list)) < size {
+			last = entity.Cursor{Distance: lp.Navigator.Distance, LastOnline: lp.LastOnline, ID: lp.ID}
 		}
-		elapsed := time.Since(p.LastOnline)
-		if elapsed.Minutes() < 5 {
-			lp.IsOnline = true
+		list = append(list, lp)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectList, method Err by path internal/storage/psql/profile/profile.go",
+			zap.Error(err))
+		return nil, err
+	}
+	var paging *entity.Pagination
+	if qp.Cursor != "" {
+		paging = entity.GetCursorPagination(size, len(list), entity.EncodeCursor(&last))
+		if uint64(len(list)) > size {
+			list = list[:size]
 		}
-		if len(images) > 0 {
-			i := entity.ResponseImageProfile{
-				Url: images[0].Url,
-			}
-			lp.Image = &i
+	} else {
+		paging = entity.GetPagination(size, page, totalItems)
+		if paging.HasNext && len(list) > 0 {
+			paging.NextCursor = entity.EncodeCursor(&last)
 		}
-		list = append(list, &lp)
 	}
-	paging := entity.GetPagination(size, page, totalItems)
 	response := entity.ResponseListProfile{
 		Pagination: paging,
 		Content:    list,
@@ -293,61 +309,102 @@ func (r *ProfileRepo) SelectList(
 	return &response, nil
 }
 
+// SelectDeck returns the next profiles of the swipe deck: the search of the viewer without the profiles the viewer
+// has liked, matched or blocked, was blocked by, or passed after passedAfter
+func (r *ProfileRepo) SelectDeck(ctx context.Context, p *entity.Profile, qp *entity.QueryParamsProfileList,
+	passedAfter time.Time) (*entity.ResponseDeck, error) {
+	from, where, queryParams := listFilter(p, qp)
+	query := listSelect + from + listImageJoin + where +
+		" AND NOT EXISTS (SELECT 1 FROM profile_likes WHERE profile_id = $4 AND likedUser_id = p.id AND" +
+		" is_liked=true) AND" +
+		" NOT EXISTS (SELECT 1 FROM profile_matches" +
+		" WHERE profile_id = LEAST($4, p.id) AND matched_user_id = GREATEST($4, p.id)) AND" +
+		" NOT EXISTS (SELECT 1 FROM profile_blocks WHERE profile_id = p.id AND blocked_user_id = $4 AND" +
+		" is_blocked=true) AND" +
+		" NOT EXISTS (SELECT 1 FROM profile_passes WHERE profile_id = $4 AND passed_user_id = p.id AND" +
+		" updated_at > $12)" +
+		" ORDER BY distance ASC, p.last_online DESC, p.id ASC LIMIT $13"
+	queryParams = append(queryParams, passedAfter, qp.Size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		r.logger.Debug("error func SelectDeck, method QueryContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.ContentListProfile, 0)
+	for rows.Next() {
+		lp, err := scanListProfile(rows)
+		if err != nil {
+			r.logger.Debug("error func SelectDeck, method Scan by path internal/storage/psql/profile/profile.go",
+				zap.Error(err))
+			return nil, err
+		}
+		list = append(list, lp)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectDeck, method Err by path internal/storage/psql/profile/profile.go",
+			zap.Error(err))
+		return nil, err
+	}
+	return &entity.ResponseDeck{Content: list}, nil
+}
+
+// AddPass records that the viewer skipped the profile, a repeated pass starts the cooldown again
+func (r *ProfileRepo) AddPass(ctx context.Context, p *entity.PassProfile) (*entity.PassProfile, error) {
+	query := `INSERT INTO profile_passes (profile_id, passed_user_id, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (profile_id, passed_user_id) DO UPDATE SET updated_at = EXCLUDED.updated_at
+			  RETURNING id, created_at`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.PassedUserID, &p.CreatedAt,
+		&p.UpdatedAt).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		r.logger.Debug("error func AddPass, method QueryRowContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return p, nil
+}
+
 func (r *ProfileRepo) AddTelegram(
 	ctx context.Context, p *entity.TelegramProfile) (*entity.TelegramProfile, error) {
 	query := "INSERT INTO profile_telegram (profile_id, telegram_id, username, first_name, last_name, language_code," +
 		" allows_write_to_pm, query_id, chat_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.TelegramID, &p.UserName, &p.Firstname, &p.Lastname,
-		&p.LanguageCode, &p.AllowsWriteToPm, &p.QueryID, &p.ChatID).Scan(&p.ID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.TelegramID, &p.UserName, &p.Firstname,
+		&p.Lastname, &p.LanguageCode, &p.AllowsWriteToPm, &p.QueryID, &p.ChatID).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddTelegram, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateTelegram(
 	ctx context.Context, p *entity.TelegramProfile) (*entity.TelegramProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateTelegram, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_telegram SET username=$1, first_name=$2, last_name=$3, language_code=$4," +
 		" allows_write_to_pm=$5 WHERE id=$6"
-	_, err = r.db.ExecContext(ctx, query, &p.UserName, &p.Firstname, &p.Lastname, &p.LanguageCode, &p.AllowsWriteToPm,
-		&p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.UserName, &p.Firstname, &p.Lastname, &p.LanguageCode,
+		&p.AllowsWriteToPm, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func UpdateTelegram, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteTelegram(
 	ctx context.Context, p *entity.TelegramProfile) (*entity.TelegramProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func DeleteTelegram, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_telegram SET telegram_id=$1, username=$2, first_name=$3, last_name=$4, language_code=$5," +
 		" allows_write_to_pm=$6, query_id=$7, chat_id=$8 WHERE id=$9"
-	_, err = r.db.ExecContext(ctx, query, &p.TelegramID, &p.UserName, &p.Firstname, &p.Lastname, &p.LanguageCode,
-		&p.AllowsWriteToPm, &p.QueryID, &p.ChatID, &p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.TelegramID, &p.UserName, &p.Firstname, &p.Lastname,
+		&p.LanguageCode, &p.AllowsWriteToPm, &p.QueryID, &p.ChatID, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func DeleteTelegram, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
@@ -358,19 +415,13 @@ func (r *ProfileRepo) FindTelegramByProfileID(
        query_id, chat_id
 			  FROM profile_telegram
 			  WHERE profile_id = $1`
-	row := r.db.QueryRowContext(ctx, query, profileID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindTelegramByProfileID, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, profileID)
 	err := row.Scan(&p.ID, &p.ProfileID, &p.TelegramID, &p.UserName, &p.Firstname, &p.Lastname, &p.LanguageCode,
 		&p.AllowsWriteToPm, &p.QueryID, &p.ChatID)
 	if err != nil {
 		r.logger.Debug("error func FindTelegramByProfileID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
@@ -379,56 +430,42 @@ func (r *ProfileRepo) AddNavigator(
 	ctx context.Context, p *entity.NavigatorProfile) (*entity.NavigatorProfile, error) {
 	query := "INSERT INTO profile_navigators (profile_id, location)" +
 		" VALUES ($1, ST_SetSRID(ST_MakePoint($2, $3),  4326)) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.Location.Longitude, &p.Location.Latitude).Scan(&p.ID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.Location.Longitude,
+		&p.Location.Latitude).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddNavigator, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateNavigator(
 	ctx context.Context, p *entity.NavigatorProfile) (*entity.NavigatorProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateNavigator, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_navigators SET location=ST_SetSRID(ST_MakePoint($1, $2),  4326) WHERE profile_id=$3"
-	_, err = r.db.ExecContext(ctx, query, &p.Location.Longitude, &p.Location.Latitude, &p.ProfileID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.Location.Longitude, &p.Location.Latitude, &p.ProfileID)
 	if err != nil {
 		r.logger.Debug("error func UpdateNavigator, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteNavigator(
 	ctx context.Context, p *entity.NavigatorProfile) (*entity.NavigatorProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func DeleteNavigator, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_navigators SET location=ST_SetSRID(ST_MakePoint($1, $2),  4326) WHERE id=$3"
-	_, err = r.db.ExecContext(ctx, query, &p.Location.Longitude, &p.Location.Latitude, &p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.Location.Longitude, &p.Location.Latitude, &p.ID)
 	if err != nil {
 		r.logger.Debug(
 			"error func DeleteNavigator, method QueryRowContext by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
+// FindNavigatorByProfileID returns the navigator of the profile, Location is nil when the coordinates are unknown
 func (r *ProfileRepo) FindNavigatorByProfileID(
 	ctx context.Context, profileID uint64) (*entity.NavigatorProfile, error) {
 	p := entity.NavigatorProfile{}
@@ -437,22 +474,15 @@ func (r *ProfileRepo) FindNavigatorByProfileID(
 	query := `SELECT id, profile_id, ST_X(location) as longitude, ST_Y(location) as latitude
 			  FROM profile_navigators
 			  WHERE profile_id = $1`
-	row := r.db.QueryRowContext(ctx, query, profileID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug(
-			"error func FindNavigatorById, method QueryRowContext by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, profileID)
 	err := row.Scan(&p.ID, &p.ProfileID, &longitude, &latitude)
 	if err != nil {
 		r.logger.Debug("error func FindNavigatorById, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	if !longitude.Valid && !latitude.Valid {
-		return nil, err
+	if !longitude.Valid || !latitude.Valid {
+		return &p, nil
 	}
 	p.Location = &entity.Point{
 		Latitude:  latitude.Float64,
@@ -465,18 +495,22 @@ func (r *ProfileRepo) FindNavigatorByProfileIDAndViewerID(
 	ctx context.Context, profileID uint64, viewerID uint64) (*entity.ResponseNavigatorProfile, error) {
 	// Get coordinates for viewerID
 	vn, err := r.FindNavigatorByProfileID(ctx, viewerID)
-	if err != nil {
+	if err != nil && !errors.Is(err, usecases.ErrNotFound) {
 		r.logger.Debug("error func FindNavigatorByProfileIDAndViewerID, method FindNavigatorByProfileID by path"+
 			" internal/handler/profile/profile.go", zap.Error(err))
 		return nil, err
 	}
 	// Get coordinates for profileID
 	pn, err := r.FindNavigatorByProfileID(ctx, profileID)
-	if err != nil {
+	if err != nil && !errors.Is(err, usecases.ErrNotFound) {
 		r.logger.Debug("error func FindNavigatorByProfileIDAndViewerID, method FindNavigatorByProfileID by path"+
 			" internal/handler/profile/profile.go", zap.Error(err))
 		return nil, err
 	}
+	// the distance is unknown until both profiles share the location, the profile is shown without it
+	if vn == nil || pn == nil || vn.Location == nil || pn.Location == nil {
+		return nil, nil
+	}
 	p := entity.NavigatorProfile{}
 	var longitude sql.NullFloat64
 	var latitude sql.NullFloat64
@@ -488,19 +522,13 @@ func (r *ProfileRepo) FindNavigatorByProfileIDAndViewerID(
 						) as distance
 			  FROM profile_navigators
 			  WHERE profile_id = $5`
-	row := r.db.QueryRowContext(ctx, query, vn.Location.Longitude, vn.Location.Latitude, pn.Location.Longitude,
-		pn.Location.Latitude, profileID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindNavigatorByProfileIDAndViewerID, method QueryRowContext by path "+
-			"internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, vn.Location.Longitude, vn.Location.Latitude,
+		pn.Location.Longitude, pn.Location.Latitude, profileID)
 	err = row.Scan(&p.ID, &p.ProfileID, &longitude, &latitude, &distance)
 	if err != nil {
 		r.logger.Debug("error func FindNavigatorByProfileIDAndViewerID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	response := &entity.ResponseNavigatorProfile{
 		Distance: distance.Float64,
@@ -511,169 +539,169 @@ func (r *ProfileRepo) FindNavigatorByProfileIDAndViewerID(
 func (r *ProfileRepo) AddFilter(
 	ctx context.Context, p *entity.FilterProfile) (*entity.FilterProfile, error) {
 	query := "INSERT INTO profile_filters (profile_id, search_gender, looking_for, age_from, age_to, distance, page," +
-		" size) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
-		&p.Distance, &p.Page, &p.Size).Scan(&p.ID)
+		" size, height_from, height_to, weight_from, weight_to) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11," +
+		" $12) RETURNING id"
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.SearchGender, &p.LookingFor, &p.AgeFrom,
+		&p.AgeTo, &p.Distance, &p.Page, &p.Size, &p.HeightFrom, &p.HeightTo, &p.WeightFrom, &p.WeightTo).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddFilter, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateFilter(
 	ctx context.Context, p *entity.FilterProfile) (*entity.FilterProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateFilter, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_filters SET search_gender=$1, looking_for=$2, age_from=$3, age_to=$4, distance=$5," +
-		" page=$6, size=$7 WHERE id=$8"
-	_, err = r.db.ExecContext(ctx, query, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
-		&p.Distance, &p.Page, &p.Size, &p.ID)
+		" page=$6, size=$7, height_from=$8, height_to=$9, weight_from=$10, weight_to=$11 WHERE id=$12"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
+		&p.Distance, &p.Page, &p.Size, &p.HeightFrom, &p.HeightTo, &p.WeightFrom, &p.WeightTo, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func UpdateFilter, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteFilter(
 	ctx context.Context, p *entity.FilterProfile) (*entity.FilterProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func DeleteFilter, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_filters SET search_gender=$1, looking_for=$2, age_from=$3, age_to=$4, distance=$5," +
-		" page=$6, size=$7 WHERE id=$8"
-	_, err = r.db.ExecContext(ctx, query, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
-		&p.Distance, &p.Page, &p.Size, &p.ID)
+		" page=$6, size=$7, height_from=$8, height_to=$9, weight_from=$10, weight_to=$11 WHERE id=$12"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
+		&p.Distance, &p.Page, &p.Size, &p.HeightFrom, &p.HeightTo, &p.WeightFrom, &p.WeightTo, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func DeleteFilter, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) FindFilterByProfileID(
 	ctx context.Context, profileID uint64) (*entity.FilterProfile, error) {
 	p := entity.FilterProfile{}
-	query := `SELECT id, profile_id, search_gender, looking_for, age_from, age_to, distance, page, size
+	query := `SELECT id, profile_id, search_gender, looking_for, age_from, age_to, distance, page, size,
+       height_from, height_to, weight_from, weight_to
 			  FROM profile_filters
 			  WHERE profile_id = $1`
-	row := r.db.QueryRowContext(ctx, query, profileID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindFilterByProfileID, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, profileID)
 	err := row.Scan(&p.ID, &p.ProfileID, &p.SearchGender, &p.LookingFor, &p.AgeFrom, &p.AgeTo,
-		&p.Distance, &p.Page, &p.Size)
+		&p.Distance, &p.Page, &p.Size, &p.HeightFrom, &p.HeightTo, &p.WeightFrom, &p.WeightTo)
 	if err != nil {
 		r.logger.Debug("error func FindFilterByProfileID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
 
 func (r *ProfileRepo) AddImage(ctx context.Context, p *entity.ImageProfile) (*entity.ImageProfile, error) {
-	query := "INSERT INTO profile_images (profile_id, name, url, size, created_at, updated_at, is_deleted," +
-		" is_blocked, is_primary, is_private) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.Name, &p.Url, &p.Size, &p.CreatedAt, &p.UpdatedAt,
-		&p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate).Scan(&p.ID)
+	query := "INSERT INTO profile_images (profile_id, name, url, thumbnail_url, size, created_at, updated_at," +
+		" is_deleted, is_blocked, is_primary, is_private) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)" +
+		" RETURNING id"
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.Name, &p.Url, &p.ThumbnailUrl, &p.Size,
+		&p.CreatedAt, &p.UpdatedAt, &p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug(
 			"error func AddImage, method QueryRowContext by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
+// UpdateImage replaces the file of the image, the primary image is chosen by SetPrimaryImage only
 func (r *ProfileRepo) UpdateImage(ctx context.Context, p *entity.ImageProfile) (*entity.ImageProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateImage, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
-	query := "UPDATE profile_images SET name=$1, url=$2, size=$3, updated_at=$4, is_deleted=$5, is_blocked=$6," +
-		" is_primary=$7, is_private=$8 WHERE id=$9"
-	_, err = r.db.ExecContext(ctx, query, &p.Name, &p.Url, &p.Size, &p.UpdatedAt, &p.IsDeleted, &p.IsBlocked,
-		&p.IsPrimary, &p.IsPrivate, &p.ID)
+	query := "UPDATE profile_images SET name=$1, url=$2, thumbnail_url=$3, size=$4, updated_at=$5, is_deleted=$6," +
+		" is_blocked=$7, is_private=$8 WHERE id=$9"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.Name, &p.Url, &p.ThumbnailUrl, &p.Size, &p.UpdatedAt,
+		&p.IsDeleted, &p.IsBlocked, &p.IsPrivate, &p.ID)
 	if err != nil {
 		r.logger.Debug(
 			"error func UpdateImage method QueryRowContext by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteImage(ctx context.Context, p *entity.ImageProfile) (*entity.ImageProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func DeleteImage, method Begin by path internal/storage/psql/profile/profile.go",
-			zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_images SET is_deleted=$1 WHERE id=$2"
-	_, err = r.db.ExecContext(ctx, query, &p.IsDeleted, &p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.IsDeleted, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func DeleteImage method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
+// SetPrimaryImage makes the image the only primary one of the profile, it must run within a transaction
+func (r *ProfileRepo) SetPrimaryImage(ctx context.Context, profileID uint64, imageID uint64) error {
+	// the unique index allows one primary image, so the previous one is reset first
+	query := "UPDATE profile_images SET is_primary=false WHERE profile_id=$1 AND is_primary=true AND id<>$2"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, profileID, imageID); err != nil {
+		r.logger.Debug("error func SetPrimaryImage, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return translate(err)
+	}
+	query = "UPDATE profile_images SET is_primary=true WHERE id=$1 AND profile_id=$2 AND is_deleted=false"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, imageID, profileID)
+	if err != nil {
+		r.logger.Debug("error func SetPrimaryImage, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return translate(err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return usecases.ErrNotFound
+	}
+	return nil
+}
+
+// EnsurePrimaryImage makes the oldest public image primary when the profile has no primary image,
+// e.g. after the first upload or after the primary image was deleted
+func (r *ProfileRepo) EnsurePrimaryImage(ctx context.Context, profileID uint64) error {
+	query := `UPDATE profile_images SET is_primary=true
+			  WHERE id = (SELECT id FROM profile_images
+			              WHERE profile_id=$1 AND is_deleted=false AND is_blocked=false AND is_private=false
+			              ORDER BY id ASC LIMIT 1)
+			    AND NOT EXISTS (SELECT 1 FROM profile_images
+			                    WHERE profile_id=$1 AND is_primary=true AND is_deleted=false)`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, profileID); err != nil {
+		r.logger.Debug("error func EnsurePrimaryImage, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return translate(err)
+	}
+	return nil
+}
+
 func (r *ProfileRepo) FindImageById(ctx context.Context, imageID uint64) (*entity.ImageProfile, error) {
 	p := entity.ImageProfile{}
-	query := `SELECT id, profile_id, name, url, size, created_at, updated_at, is_deleted, is_blocked, is_primary,
-       is_private
+	query := `SELECT id, profile_id, name, url, thumbnail_url, size, created_at, updated_at, is_deleted, is_blocked,
+       is_primary, is_private
 			  FROM profile_images
 			  WHERE id=$1 AND is_deleted=false AND is_blocked=false`
-	row := r.db.QueryRowContext(ctx, query, imageID)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindImageById, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	err := row.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.Size, &p.CreatedAt, &p.UpdatedAt,
-		&p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, imageID)
+	err := row.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.ThumbnailUrl, &p.Size, &p.CreatedAt,
+		&p.UpdatedAt, &p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
 	if err != nil {
 		r.logger.Debug("error func FindImageById, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
 
 func (r *ProfileRepo) SelectListPublicImage(
 	ctx context.Context, profileID uint64) ([]*entity.ImageProfile, error) {
-	query := `SELECT id, profile_id, name, url, size, created_at, updated_at, is_deleted, is_blocked, is_primary,
-       is_private
+	query := `SELECT id, profile_id, name, url, thumbnail_url, size, created_at, updated_at, is_deleted, is_blocked,
+       is_primary, is_private
 	FROM profile_images
-	WHERE profile_id=$1 AND is_deleted=false AND is_blocked=false AND is_private=false`
-	rows, err := r.db.QueryContext(ctx, query, profileID)
+	WHERE profile_id=$1 AND is_deleted=false AND is_blocked=false AND is_private=false
+	ORDER BY is_primary DESC, id ASC`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
 	if err != nil {
 		r.logger.Debug("error func SelectListPublicImage,"+
 			" method QueryContext by path internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -683,8 +711,8 @@ func (r *ProfileRepo) SelectListPublicImage(
 	list := make([]*entity.ImageProfile, 0)
 	for rows.Next() {
 		p := entity.ImageProfile{}
-		err := rows.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.Size, &p.CreatedAt, &p.UpdatedAt, &p.IsDeleted,
-			&p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
+		err := rows.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.ThumbnailUrl, &p.Size, &p.CreatedAt,
+			&p.UpdatedAt, &p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
 		if err != nil {
 			r.logger.Debug("error func SelectListPublicImage,"+
 				" method Scan by path internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -697,11 +725,11 @@ func (r *ProfileRepo) SelectListPublicImage(
 
 func (r *ProfileRepo) SelectListImage(
 	ctx context.Context, profileID uint64) ([]*entity.ImageProfile, error) {
-	query := `SELECT id, profile_id, name, url, size, created_at, updated_at, is_deleted, is_blocked, is_primary,
-       is_private
+	query := `SELECT id, profile_id, name, url, thumbnail_url, size, created_at, updated_at, is_deleted, is_blocked,
+       is_primary, is_private
 	FROM profile_images
 	WHERE profile_id=$1`
-	rows, err := r.db.QueryContext(ctx, query, profileID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
 	if err != nil {
 		r.logger.Debug("error func SelectListImage,"+
 			" method QueryContext by path internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -711,8 +739,8 @@ func (r *ProfileRepo) SelectListImage(
 	list := make([]*entity.ImageProfile, 0)
 	for rows.Next() {
 		p := entity.ImageProfile{}
-		err := rows.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.Size, &p.CreatedAt, &p.UpdatedAt, &p.IsDeleted,
-			&p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
+		err := rows.Scan(&p.ID, &p.ProfileID, &p.Name, &p.Url, &p.ThumbnailUrl, &p.Size, &p.CreatedAt,
+			&p.UpdatedAt, &p.IsDeleted, &p.IsBlocked, &p.IsPrimary, &p.IsPrivate)
 		if err != nil {
 			r.logger.Debug("error func SelectListImage,"+
 				" method Scan by path internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -728,7 +756,7 @@ func (r *ProfileRepo) CheckIfCommonImageExists(
 	var imageID uint64
 	query := "SELECT id" +
 		" FROM profile_images WHERE profile_id=$1 AND name=$2"
-	err := r.db.QueryRowContext(ctx, query, profileID, fileName).Scan(&imageID)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, profileID, fileName).Scan(&imageID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, 0, nil
@@ -741,58 +769,42 @@ func (r *ProfileRepo) CheckIfCommonImageExists(
 func (r *ProfileRepo) AddReview(ctx context.Context, p *entity.ReviewProfile) (*entity.ReviewProfile, error) {
 	query := "INSERT INTO profile_reviews (profile_id, message, rating, has_deleted, has_edited," +
 		" created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
 		&p.HasEdited, &p.CreatedAt, &p.UpdatedAt).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddReview, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateReview(
 	ctx context.Context, p *entity.ReviewProfile) (*entity.ReviewProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateReview, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_reviews SET profile_id=$1, message=$2, rating=$3, has_deleted=$4," +
 		" has_edited=$5, created_at=$6, updated_at=$7 WHERE id=$8 AND has_deleted=false"
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
 		&p.HasEdited, &p.CreatedAt, &p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug(
 			"error func UpdateReview, method ExecContext by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteReview(
 	ctx context.Context, p *entity.ReviewProfile) (*entity.ReviewProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug(
-			"error func DeleteReview, method Begin by path internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := "UPDATE profile_reviews SET profile_id=$1, message=$2, rating=$3, has_deleted=$4," +
 		" has_edited=$5, created_at=$6, updated_at=$7 WHERE id=$8 AND has_deleted=false"
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
 		&p.HasEdited, &p.CreatedAt, &p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func DeleteReview, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
@@ -803,19 +815,13 @@ func (r *ProfileRepo) FindReviewById(ctx context.Context, id uint64) (*entity.Re
 			  FROM profile_reviews pr
               JOIN profiles p ON pr.profile_id = p.id
 			  WHERE pr.id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
-	if row == nil {
-		err := errors.New("no rows found")
-		r.logger.Debug("error func FindReviewById, method QueryRowContext by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
 	err := row.Scan(&p.ID, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted,
 		&p.HasEdited, &p.CreatedAt, &p.UpdatedAt, &p.SessionID)
 	if err != nil {
 		r.logger.Debug("error func FindReviewById, method Scan by path internal/storage/psql/profile/profile.go",
 			zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return &p, nil
 }
@@ -826,8 +832,7 @@ func (r *ProfileRepo) SelectReviewList(
                 pr.updated_at, p.display_name, p.session_id
               FROM profile_reviews pr
               JOIN profiles p ON pr.profile_id = p.id
-              WHERE has_deleted=false
-              ORDER BY pr.created_at DESC`
+              WHERE has_deleted=false`
 	// Query to get number of reviews
 	countQuery := `SELECT COUNT(*) FROM profile_reviews pr
                      WHERE pr.has_deleted=false`
@@ -836,14 +841,9 @@ func (r *ProfileRepo) SelectReviewList(
 					SELECT COUNT(*)
 					FROM profile_reviews pr
                     WHERE pr.profile_id=$1 AND pr.created_at::date = CURRENT_DATE`
-	profileID, err := strconv.ParseUint(qp.ProfileID, 10, 64)
-	if err != nil {
-		r.logger.Debug("error func SelectReviewList, method ParseUint by path"+
-			" internal/handler/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
+	profileID := qp.ProfileID
 	var count uint
-	err = r.db.QueryRowContext(ctx, countReviewsOnCurrentDateByProfileID, profileID).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, countReviewsOnCurrentDateByProfileID, profileID).Scan(&count)
 	if err != nil {
 		r.logger.Debug("error func SelectReviewList, method QueryRowContext for avgRating by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -855,7 +855,7 @@ func (r *ProfileRepo) SelectReviewList(
                       JOIN profiles p ON pr.profile_id = p.id
                       WHERE pr.has_deleted=false`
 	var avgRating float32
-	err = r.db.QueryRowContext(ctx, avgRatingQuery).Scan(&avgRating)
+	err = conn(ctx, r.db).QueryRowContext(ctx, avgRatingQuery).Scan(&avgRating)
 	if err != nil {
 		r.logger.Debug("error func SelectReviewList, method QueryRowContext for avgRating by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -865,17 +865,32 @@ func (r *ProfileRepo) SelectReviewList(
 	roundedAvgRating := float32(math.Round(float64(avgRating)*2)) / 2
 	size := qp.Size
 	page := qp.Page
-	// get totalItems
-	totalItems, err := entity.GetTotalItems(ctx, r.db, countQuery)
-	if err != nil {
-		r.logger.Debug("error func SelectReviewList, method GetTotalItems by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+	var totalItems uint64
+	queryParams := make([]interface{}, 0, 2)
+	if qp.Cursor != "" {
+		c, err := entity.DecodeCursor(qp.Cursor)
+		if err != nil {
+			r.logger.Debug("error func SelectReviewList, method DecodeCursor by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		// the reviews older than the cursor, id breaks the ties of created_at
+		query += " AND (pr.created_at, pr.id) < ($1, $2) ORDER BY pr.created_at DESC, pr.id DESC"
+		query = entity.ApplyLimit(query, size)
+		queryParams = append(queryParams, c.CreatedAt, c.ID)
+	} else {
+		// get totalItems
+		totalItems, err = entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery)
+		if err != nil {
+			r.logger.Debug("error func SelectReviewList, method GetTotalItems by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		// pagination
+		query += " ORDER BY pr.created_at DESC, pr.id DESC"
+		query = entity.ApplyPagination(query, page, size)
 	}
-	// pagination
-	query = entity.ApplyPagination(query, page, size)
-	countQuery = entity.ApplyPagination(countQuery, page, size)
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		r.logger.Debug("error func SelectReviewList, method QueryContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -883,6 +898,7 @@ func (r *ProfileRepo) SelectReviewList(
 	}
 	defer rows.Close()
 	list := make([]*entity.ContentReviewProfile, 0)
+	var last entity.Cursor
 	for rows.Next() {
 		p := entity.ContentReviewProfile{}
 		err := rows.Scan(&p.ID, &p.ProfileID, &p.Message, &p.Rating, &p.HasDeleted, &p.HasEdited, &p.CreatedAt,
@@ -890,11 +906,30 @@ func (r *ProfileRepo) SelectReviewList(
 		if err != nil {
 			r.logger.Debug("error func SelectReviewList, method Scan by path"+
 				" internal/storage/psql/profile/profile.go", zap.Error(err))
-			continue
+			return nil, err
+		}
+		if uint64(len(list)) < size {
+			last = entity.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
 		}
 		list = append(list, &p)
 	}
-	paging := entity.GetPagination(size, page, totalItems)
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectReviewList, method Err by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	var paging *entity.Pagination
+	if qp.Cursor != "" {
+		paging = entity.GetCursorPagination(size, len(list), entity.EncodeCursor(&last))
+		if uint64(len(list)) > size {
+			list = list[:size]
+		}
+	} else {
+		paging = entity.GetPagination(size, page, totalItems)
+		if paging.HasNext && len(list) > 0 {
+			paging.NextCursor = entity.EncodeCursor(&last)
+		}
+	}
 	response := entity.ResponseListReview{
 		Pagination:               paging,
 		RatingAverage:            roundedAvgRating,
@@ -907,56 +942,44 @@ func (r *ProfileRepo) SelectReviewList(
 func (r *ProfileRepo) AddLike(ctx context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error) {
 	query := `INSERT INTO profile_likes (profile_id, likedUser_id, is_liked, created_at, updated_at)
 			  VALUES ($1, $2, $3, $4, $5)
-			  RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt,
-		&p.UpdatedAt).Scan(&p.ID)
+			  ON CONFLICT (profile_id, likedUser_id)
+			  DO UPDATE SET is_liked=EXCLUDED.is_liked, updated_at=EXCLUDED.updated_at
+			  RETURNING id, created_at`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt,
+		&p.UpdatedAt).Scan(&p.ID, &p.CreatedAt)
 	if err != nil {
 		r.logger.Debug("error func AddLike, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateLike(ctx context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateLike, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := `UPDATE profile_likes
 			  SET profile_id=$1, likedUser_id=$2, is_liked=$3, created_at=$4, updated_at=$5
 			  WHERE id=$6`
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt, &p.UpdatedAt, &p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt,
+		&p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func UpdateLike, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
 func (r *ProfileRepo) DeleteLike(ctx context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func DeleteLike, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := `UPDATE profile_likes
 			  SET profile_id=$1, likedUser_id=$2, is_liked=$3, created_at=$4, updated_at=$5
 			  WHERE id=$6`
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt, &p.UpdatedAt, &p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt,
+		&p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func DeleteLike, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
@@ -966,7 +989,7 @@ func (r *ProfileRepo) FindLikeByLikedUserID(
 	query := `SELECT id, profile_id, likedUser_id, is_liked, created_at, updated_at
 			  FROM profile_likes
 			  WHERE profile_id=$1 AND likedUser_id = $2`
-	err := r.db.QueryRowContext(ctx, query, profileID, likedUserID).
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, profileID, likedUserID).
 		Scan(&p.ID, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -974,7 +997,7 @@ func (r *ProfileRepo) FindLikeByLikedUserID(
 		}
 		r.logger.Debug("error func FindLikeByLikedUserID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, false, err
+		return nil, false, translate(err)
 	}
 	return &p, true, nil
 }
@@ -984,7 +1007,7 @@ func (r *ProfileRepo) FindLikeByID(ctx context.Context, id uint64) (*entity.Like
 	query := `SELECT id, profile_id, likedUser_id, is_liked, created_at, updated_at
 			  FROM profile_likes
 			  WHERE id=$1`
-	err := r.db.QueryRowContext(ctx, query, id).
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
 		Scan(&p.ID, &p.ProfileID, &p.LikedUserID, &p.IsLiked, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -992,46 +1015,328 @@ func (r *ProfileRepo) FindLikeByID(ctx context.Context, id uint64) (*entity.Like
 		}
 		r.logger.Debug("error func FindLikeByID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, false, err
+		return nil, false, translate(err)
+	}
+	return &p, true, nil
+}
+
+func (r *ProfileRepo) SelectListIncomingLike(
+	ctx context.Context, profileID uint64, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error) {
+	query := `SELECT pl.id, pl.updated_at, p.id, p.last_online,
+			    ST_DistanceSphere(pn.location, vn.location) as distance,
+			    (SELECT pi.url FROM profile_images pi
+			     WHERE pi.profile_id = p.id AND pi.is_deleted=false AND pi.is_blocked=false AND pi.is_private=false
+			     ORDER BY pi.is_primary DESC, pi.id ASC LIMIT 1) as url
+			  FROM profile_likes pl
+			  JOIN profiles p ON p.id = pl.profile_id
+			  LEFT JOIN profile_navigators pn ON pn.profile_id = p.id
+			  LEFT JOIN profile_navigators vn ON vn.profile_id = $1
+			  WHERE pl.likedUser_id = $1 AND pl.is_liked=true AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			    AND NOT EXISTS (SELECT 1 FROM profile_matches pm WHERE pm.is_deleted=true AND
+			        ((pm.profile_id = $1 AND pm.matched_user_id = p.id) OR (pm.profile_id = p.id AND pm.matched_user_id = $1)))
+			  ORDER BY pl.updated_at DESC, pl.id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM profile_likes pl
+			  JOIN profiles p ON p.id = pl.profile_id
+			  WHERE pl.likedUser_id = $1 AND pl.is_liked=true AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			    AND NOT EXISTS (SELECT 1 FROM profile_matches pm WHERE pm.is_deleted=true AND
+			        ((pm.profile_id = $1 AND pm.matched_user_id = p.id) OR (pm.profile_id = p.id AND pm.matched_user_id = $1)))`
+	return r.selectListLike(ctx, query, countQuery, profileID, qp)
+}
+
+func (r *ProfileRepo) SelectListOutgoingLike(
+	ctx context.Context, profileID uint64, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error) {
+	query := `SELECT pl.id, pl.updated_at, p.id, p.last_online,
+			    ST_DistanceSphere(pn.location, vn.location) as distance,
+			    (SELECT pi.url FROM profile_images pi
+			     WHERE pi.profile_id = p.id AND pi.is_deleted=false AND pi.is_blocked=false AND pi.is_private=false
+			     ORDER BY pi.is_primary DESC, pi.id ASC LIMIT 1) as url
+			  FROM profile_likes pl
+			  JOIN profiles p ON p.id = pl.likedUser_id
+			  LEFT JOIN profile_navigators pn ON pn.profile_id = p.id
+			  LEFT JOIN profile_navigators vn ON vn.profile_id = $1
+			  WHERE pl.profile_id = $1 AND pl.is_liked=true AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			    AND NOT EXISTS (SELECT 1 FROM profile_matches pm WHERE pm.is_deleted=true AND
+			        ((pm.profile_id = $1 AND pm.matched_user_id = p.id) OR (pm.profile_id = p.id AND pm.matched_user_id = $1)))
+			  ORDER BY pl.updated_at DESC, pl.id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM profile_likes pl
+			  JOIN profiles p ON p.id = pl.likedUser_id
+			  WHERE pl.profile_id = $1 AND pl.is_liked=true AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			    AND NOT EXISTS (SELECT 1 FROM profile_matches pm WHERE pm.is_deleted=true AND
+			        ((pm.profile_id = $1 AND pm.matched_user_id = p.id) OR (pm.profile_id = p.id AND pm.matched_user_id = $1)))`
+	return r.selectListLike(ctx, query, countQuery, profileID, qp)
+}
+
+// selectListLike runs one of the like list queries; both expect the viewer profile id as $1
+func (r *ProfileRepo) selectListLike(ctx context.Context, query, countQuery string, profileID uint64,
+	qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error) {
+	size := qp.Size
+	page := qp.Page
+	// get totalItems
+	totalItems, err := entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, profileID)
+	if err != nil {
+		r.logger.Debug("error func selectListLike, method GetTotalItems by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	// pagination
+	query = entity.ApplyPagination(query, page, size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
+	if err != nil {
+		r.logger.Debug("error func selectListLike, method QueryContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.ContentListLike, 0)
+	for rows.Next() {
+		l := entity.ContentListLike{}
+		var distance sql.NullFloat64
+		var url sql.NullString
+		err := rows.Scan(&l.LikeID, &l.LikedAt, &l.ID, &l.LastOnline, &distance, &url)
+		if err != nil {
+			r.logger.Debug("error func selectListLike, method Scan by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		elapsed := time.Since(l.LastOnline)
+		if elapsed.Minutes() < 5 {
+			l.IsOnline = true
+		}
+		if distance.Valid {
+			l.Navigator = &entity.ResponseNavigatorProfile{
+				Distance: distance.Float64,
+			}
+		}
+		if url.Valid {
+			l.Image = &entity.ResponseImageProfile{
+				Url: url.String,
+			}
+		}
+		list = append(list, &l)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func selectListLike, method Err by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	paging := entity.GetPagination(size, page, totalItems)
+	response := entity.ResponseListLike{
+		Pagination: paging,
+		Content:    list,
+	}
+	return &response, nil
+}
+
+// AddMatch stores a match once per pair of profiles. The pair is kept ordered (lower id first), so a repeated
+// mutual like returns the existing match with isCreated=false.
+func (r *ProfileRepo) AddMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, bool, error) {
+	if p.ProfileID > p.MatchedUserID {
+		p.ProfileID, p.MatchedUserID = p.MatchedUserID, p.ProfileID
+	}
+	query := `INSERT INTO profile_matches (profile_id, matched_user_id, is_deleted, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (profile_id, matched_user_id) DO NOTHING
+			  RETURNING id`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.MatchedUserID, &p.IsDeleted, &p.CreatedAt,
+		&p.UpdatedAt).Scan(&p.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			m, _, err := r.FindMatchByProfiles(ctx, p.ProfileID, p.MatchedUserID)
+			if err != nil {
+				return nil, false, translate(err)
+			}
+			return m, false, nil
+		}
+		r.logger.Debug("error func AddMatch, method QueryRowContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, false, translate(err)
+	}
+	return p, true, nil
+}
+
+func (r *ProfileRepo) FindMatchByProfiles(
+	ctx context.Context, profileID uint64, matchedUserID uint64) (*entity.MatchProfile, bool, error) {
+	if profileID > matchedUserID {
+		profileID, matchedUserID = matchedUserID, profileID
+	}
+	p := entity.MatchProfile{}
+	query := `SELECT id, profile_id, matched_user_id, is_deleted, created_at, updated_at
+			  FROM profile_matches
+			  WHERE profile_id=$1 AND matched_user_id=$2`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, profileID, matchedUserID).
+		Scan(&p.ID, &p.ProfileID, &p.MatchedUserID, &p.IsDeleted, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		r.logger.Debug("error func FindMatchByProfiles, method Scan by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, false, translate(err)
+	}
+	return &p, true, nil
+}
+
+func (r *ProfileRepo) FindMatchByID(ctx context.Context, id uint64) (*entity.MatchProfile, bool, error) {
+	p := entity.MatchProfile{}
+	query := `SELECT id, profile_id, matched_user_id, is_deleted, created_at, updated_at
+			  FROM profile_matches
+			  WHERE id=$1`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
+		Scan(&p.ID, &p.ProfileID, &p.MatchedUserID, &p.IsDeleted, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		r.logger.Debug("error func FindMatchByID, method Scan by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, false, translate(err)
 	}
 	return &p, true, nil
 }
 
+func (r *ProfileRepo) DeleteMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, error) {
+	query := `UPDATE profile_matches
+			  SET is_deleted=$1, updated_at=$2
+			  WHERE id=$3`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.IsDeleted, &p.UpdatedAt, &p.ID)
+	if err != nil {
+		r.logger.Debug("error func DeleteMatch, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, translate(err)
+	}
+	return p, nil
+}
+
+func (r *ProfileRepo) SelectMatchList(
+	ctx context.Context, profileID uint64, qp *entity.QueryParamsMatchList) (*entity.ResponseListMatch, error) {
+	query := `SELECT pm.id, p.id, p.display_name, p.last_online, pm.created_at, pi.url, pi.thumbnail_url
+			  FROM profile_matches pm
+			  JOIN profiles p
+			    ON p.id = CASE WHEN pm.profile_id = $1 THEN pm.matched_user_id ELSE pm.profile_id END` +
+		listImageJoin + `
+			  WHERE (pm.profile_id = $1 OR pm.matched_user_id = $1) AND pm.is_deleted=false
+			    AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)
+			  ORDER BY pm.created_at DESC, pm.id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM profile_matches pm
+			  JOIN profiles p
+			    ON p.id = CASE WHEN pm.profile_id = $1 THEN pm.matched_user_id ELSE pm.profile_id END
+			  WHERE (pm.profile_id = $1 OR pm.matched_user_id = $1) AND pm.is_deleted=false
+			    AND p.is_deleted=false AND p.is_blocked=false
+			    AND NOT EXISTS (SELECT 1 FROM profile_blocks pb
+			                    WHERE pb.profile_id = $1 AND pb.blocked_user_id = p.id AND pb.is_blocked=true)`
+	size := qp.Size
+	page := qp.Page
+	// get totalItems
+	totalItems, err := entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, profileID)
+	if err != nil {
+		r.logger.Debug("error func SelectMatchList, method GetTotalItems by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	// pagination
+	query = entity.ApplyPagination(query, page, size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
+	if err != nil {
+		r.logger.Debug("error func SelectMatchList, method QueryContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.ContentListMatch, 0)
+	for rows.Next() {
+		m := entity.ContentListMatch{}
+		var url, thumbnailUrl sql.NullString
+		err := rows.Scan(&m.ID, &m.ProfileID, &m.DisplayName, &m.LastOnline, &m.CreatedAt, &url, &thumbnailUrl)
+		if err != nil {
+			r.logger.Debug("error func SelectMatchList, method Scan by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		elapsed := time.Since(m.LastOnline)
+		if elapsed.Minutes() < 5 {
+			m.IsOnline = true
+		}
+		m.Image = listImage(url, thumbnailUrl)
+		list = append(list, &m)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Debug("error func SelectMatchList, method Err by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	paging := entity.GetPagination(size, page, totalItems)
+	response := entity.ResponseListMatch{
+		Pagination: paging,
+		Content:    list,
+	}
+	return &response, nil
+}
+
+// SelectListMatchedUserID returns ids of the profiles the given profile has an active match with
+func (r *ProfileRepo) SelectListMatchedUserID(ctx context.Context, profileID uint64) ([]uint64, error) {
+	query := `SELECT CASE WHEN profile_id = $1 THEN matched_user_id ELSE profile_id END
+			  FROM profile_matches
+			  WHERE (profile_id = $1 OR matched_user_id = $1) AND is_deleted=false`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, profileID)
+	if err != nil {
+		r.logger.Debug("error func SelectListMatchedUserID, method QueryContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]uint64, 0)
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Debug("error func SelectListMatchedUserID, method Scan by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		list = append(list, id)
+	}
+	return list, nil
+}
+
 func (r *ProfileRepo) AddBlock(
 	ctx context.Context, p *entity.BlockedProfile) (*entity.BlockedProfile, error) {
 	query := `INSERT INTO profile_blocks (profile_id, blocked_user_id, is_blocked, created_at, updated_at)
 			  VALUES ($1, $2, $3, $4, $5)
 			  RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.BlockedUserID, &p.IsBlocked, &p.CreatedAt,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.BlockedUserID, &p.IsBlocked, &p.CreatedAt,
 		&p.UpdatedAt).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddBlock, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateBlock(
 	ctx context.Context, p *entity.BlockedProfile) (*entity.BlockedProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateBlock, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := `UPDATE profile_blocks
 			  SET profile_id=$1, blocked_user_id=$2, is_blocked=$3, created_at=$4, updated_at=$5
 			  WHERE id=$6`
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.BlockedUserID, &p.IsBlocked, &p.CreatedAt, &p.UpdatedAt,
-		&p.ID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.BlockedUserID, &p.IsBlocked, &p.CreatedAt,
+		&p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func UpdateBlock, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
@@ -1040,7 +1345,7 @@ func (r *ProfileRepo) FindBlockByID(ctx context.Context, id uint64) (*entity.Blo
 	query := `SELECT id, profile_id, blocked_user_id, is_blocked, created_at, updated_at
 			  FROM profile_blocks
 			  WHERE id=$1`
-	err := r.db.QueryRowContext(ctx, query, id).
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
 		Scan(&p.ID, &p.ProfileID, &p.BlockedUserID, &p.IsBlocked, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -1048,7 +1353,7 @@ func (r *ProfileRepo) FindBlockByID(ctx context.Context, id uint64) (*entity.Blo
 		}
 		r.logger.Debug("error func FindBlockByID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, false, err
+		return nil, false, translate(err)
 	}
 	return &p, true, nil
 }
@@ -1058,36 +1363,28 @@ func (r *ProfileRepo) AddComplaint(
 	query := `INSERT INTO profile_complaints (profile_id, complaint_user_id, reason, created_at, updated_at)
 			  VALUES ($1, $2, $3, $4, $5)
 			  RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, &p.ProfileID, &p.ComplaintUserID, &p.Reason,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, &p.ProfileID, &p.ComplaintUserID, &p.Reason,
 		&p.CreatedAt, &p.UpdatedAt).Scan(&p.ID)
 	if err != nil {
 		r.logger.Debug("error func AddComplaint, method QueryRowContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
 	return p, nil
 }
 
 func (r *ProfileRepo) UpdateComplaint(
 	ctx context.Context, p *entity.ComplaintProfile) (*entity.ComplaintProfile, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		r.logger.Debug("error func UpdateComplaint, method Begin by path"+
-			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
-	}
-	defer tx.Rollback()
 	query := `UPDATE profile_complaints
 			  SET profile_id=$1, complaint_user_id=$2, reason=$3, created_at=$4, updated_at=$5
 			  WHERE id=$6`
-	_, err = r.db.ExecContext(ctx, query, &p.ProfileID, &p.ComplaintUserID, &p.Reason, &p.CreatedAt,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, &p.ProfileID, &p.ComplaintUserID, &p.Reason, &p.CreatedAt,
 		&p.UpdatedAt, &p.ID)
 	if err != nil {
 		r.logger.Debug("error func UpdateComplaint, method ExecContext by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, err
+		return nil, translate(err)
 	}
-	tx.Commit()
 	return p, nil
 }
 
@@ -1096,7 +1393,7 @@ func (r *ProfileRepo) FindComplaintByID(ctx context.Context, id uint64) (*entity
 	query := `SELECT id, profile_id, complaint_user_id, reason, created_at, updated_at
 			  FROM profile_complaints
 			  WHERE id=$1`
-	err := r.db.QueryRowContext(ctx, query, id).
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
 		Scan(&p.ID, &p.ProfileID, &p.ComplaintUserID, &p.Reason, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -1104,7 +1401,7 @@ func (r *ProfileRepo) FindComplaintByID(ctx context.Context, id uint64) (*entity
 		}
 		r.logger.Debug("error func FindComplaintByID, method Scan by path"+
 			" internal/storage/psql/profile/profile.go", zap.Error(err))
-		return nil, false, err
+		return nil, false, translate(err)
 	}
 	return &p, true, nil
 }
@@ -1114,7 +1411,7 @@ func (r *ProfileRepo) SelectListComplaintByID(
 	query := `SELECT id, profile_id, complaint_user_id, reason, created_at, updated_at
 	FROM profile_complaints
 	WHERE complaint_user_id=$1`
-	rows, err := r.db.QueryContext(ctx, query, complaintUserID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, complaintUserID)
 	if err != nil {
 		r.logger.Debug("error func SelectListComplaintByID,"+
 			" method QueryContext by path internal/storage/psql/profile/profile.go", zap.Error(err))
@@ -1134,3 +1431,146 @@ func (r *ProfileRepo) SelectListComplaintByID(
 	}
 	return list, nil
 }
+
+// SelectListByAdmin lists profiles of any status, search matches the display name, the telegram username,
+// the session id or the profile id
+func (r *ProfileRepo) SelectListByAdmin(
+	ctx context.Context, qp *entity.QueryParamsAdminProfileList) (*entity.ResponseListAdminProfile, error) {
+	isBlocked := nullBool(qp.IsBlocked)
+	isDeleted := nullBool(qp.IsDeleted)
+	isPremium := nullBool(qp.IsPremium)
+	where := `WHERE ($1 = '' OR p.display_name ILIKE '%' || $1 || '%' OR pt.username ILIKE '%' || $1 || '%'
+			    OR p.session_id = $1 OR p.id::TEXT = $1)
+			  AND ($2::BOOLEAN IS NULL OR p.is_blocked = $2::BOOLEAN)
+			  AND ($3::BOOLEAN IS NULL OR p.is_deleted = $3::BOOLEAN)
+			  AND ($4::BOOLEAN IS NULL OR p.is_premium = $4::BOOLEAN)`
+	query := `SELECT p.id, p.session_id, COALESCE(p.display_name, ''), COALESCE(p.gender, ''),
+			    COALESCE(p.location, ''), p.is_deleted, p.is_blocked, p.is_premium, p.created_at, p.last_online,
+			    COALESCE(pt.telegram_id, 0), COALESCE(pt.username, ''),
+			    (SELECT COUNT(*) FROM profile_complaints pc WHERE pc.complaint_user_id = p.id)
+			  FROM profiles p
+			  LEFT JOIN profile_telegram pt ON pt.profile_id = p.id
+			  ` + where + `
+			  ORDER BY p.id DESC`
+	countQuery := `SELECT COUNT(*)
+			  FROM profiles p
+			  LEFT JOIN profile_telegram pt ON pt.profile_id = p.id
+			  ` + where
+	search := strings.TrimSpace(qp.Search)
+	size := qp.Size
+	page := qp.Page
+	// get totalItems
+	totalItems, err := entity.GetTotalItems(ctx, conn(ctx, r.db), countQuery, search, isBlocked, isDeleted,
+		isPremium)
+	if err != nil {
+		r.logger.Debug("error func SelectListByAdmin, method GetTotalItems by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	// pagination
+	query = entity.ApplyPagination(query, page, size)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, search, isBlocked, isDeleted, isPremium)
+	if err != nil {
+		r.logger.Debug("error func SelectListByAdmin, method QueryContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]*entity.ContentAdminProfile, 0)
+	for rows.Next() {
+		p := entity.ContentAdminProfile{}
+		err := rows.Scan(&p.ID, &p.SessionID, &p.DisplayName, &p.Gender, &p.Location, &p.IsDeleted, &p.IsBlocked,
+			&p.IsPremium, &p.CreatedAt, &p.LastOnline, &p.TelegramID, &p.TelegramUserName, &p.ComplaintCount)
+		if err != nil {
+			r.logger.Debug("error func SelectListByAdmin, method Scan by path"+
+				" internal/storage/psql/profile/profile.go", zap.Error(err))
+			return nil, err
+		}
+		p.IsOnline = time.Since(p.LastOnline).Minutes() < 5
+		list = append(list, &p)
+	}
+	paging := entity.GetPagination(size, page, totalItems)
+	response := entity.ResponseListAdminProfile{
+		Pagination: paging,
+		Content:    list,
+	}
+	return &response, nil
+}
+
+// UpdateIsBlocked reports false if there is no such profile
+func (r *ProfileRepo) UpdateIsBlocked(ctx context.Context, profileID uint64, isBlocked bool) (bool, error) {
+	query := `UPDATE profiles SET is_blocked=$1, updated_at=$2 WHERE id=$3`
+	return r.updateStatus(ctx, "UpdateIsBlocked", query, isBlocked, time.Now().UTC(), profileID)
+}
+
+// UpdateIsPremium reports false if there is no such profile
+func (r *ProfileRepo) UpdateIsPremium(ctx context.Context, profileID uint64, isPremium bool) (bool, error) {
+	query := `UPDATE profiles SET is_premium=$1, updated_at=$2 WHERE id=$3`
+	return r.updateStatus(ctx, "UpdateIsPremium", query, isPremium, time.Now().UTC(), profileID)
+}
+
+// Restore reports false if there is no such deleted profile or its data has been erased
+func (r *ProfileRepo) Restore(ctx context.Context, profileID uint64) (bool, error) {
+	query := `UPDATE profiles SET is_deleted=false, updated_at=$1
+			  WHERE id=$2 AND is_deleted=true AND NOT (session_id='' AND display_name='')`
+	return r.updateStatus(ctx, "Restore", query, time.Now().UTC(), profileID)
+}
+
+func (r *ProfileRepo) updateStatus(ctx context.Context, funcName string, query string, args ...any) (bool, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Debug("error func "+funcName+", method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Debug("error func "+funcName+", method RowsAffected by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// parseNullBool treats an empty filter value as "any"
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func (r *ProfileRepo) LinkTelegram(ctx context.Context, profileID uint64, telegramID uint64) error {
+	query := `INSERT INTO profile_identities (profile_id, telegram_id, created_at) VALUES ($1, $2, $3)
+			  ON CONFLICT (profile_id) DO UPDATE SET telegram_id = EXCLUDED.telegram_id, updated_at = EXCLUDED.created_at`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, profileID, telegramID, time.Now().UTC())
+	if err != nil {
+		r.logger.Debug("error func LinkTelegram, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return translate(err)
+	}
+	return nil
+}
+
+// LinkKeycloakUser reports false if the profile is already linked to another keycloak user
+func (r *ProfileRepo) LinkKeycloakUser(ctx context.Context, profileID uint64, keycloakUserID string) (bool, error) {
+	query := `INSERT INTO profile_identities (profile_id, keycloak_user_id, created_at) VALUES ($1, $2, $3)
+			  ON CONFLICT (profile_id) DO UPDATE
+			  SET keycloak_user_id = EXCLUDED.keycloak_user_id, updated_at = EXCLUDED.created_at
+			  WHERE profile_identities.keycloak_user_id IS NULL
+			     OR profile_identities.keycloak_user_id = EXCLUDED.keycloak_user_id`
+	return r.updateStatus(ctx, "LinkKeycloakUser", query, profileID, keycloakUserID, time.Now().UTC())
+}
+
+// UnlinkIdentity frees the keycloak user and the telegram account of a deleted profile,
+// so they can be linked to a new one
+func (r *ProfileRepo) UnlinkIdentity(ctx context.Context, profileID uint64) error {
+	query := `DELETE FROM profile_identities WHERE profile_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, profileID)
+	if err != nil {
+		r.logger.Debug("error func UnlinkIdentity, method ExecContext by path"+
+			" internal/storage/psql/profile/profile.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func newMockProfileRepo(t *testing.T) (usecases.ProfileRepo, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = db.Close()
+	})
+	return NewProfileRepo(zap.NewNop(), db), mock
+}
+
+func TestProfileRepo_FindById_NotFound(t *testing.T) {
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("FROM profiles").WithArgs(42).WillReturnError(sql.ErrNoRows)
+
+	p, err := repo.FindById(context.Background(), 42)
+	if p != nil {
+		t.Errorf("FindById() = %v, want nil", p)
+	}
+	if !errors.Is(err, usecases.ErrNotFound) {
+		t.Errorf("FindById() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProfileRepo_FindNavigatorByProfileID(t *testing.T) {
+	columns := []string{"id", "profile_id", "longitude", "latitude"}
+	tests := []struct {
+		name string
+		row  []driver.Value
+		want *entity.Point
+	}{
+		{"with coordinates", []driver.Value{1, 42, 37.61, 55.75}, &entity.Point{Latitude: 55.75, Longitude: 37.61}},
+		{"null coordinates", []driver.Value{1, 42, nil, nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockProfileRepo(t)
+			mock.ExpectQuery("FROM profile_navigators").WithArgs(42).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(tt.row...))
+
+			n, err := repo.FindNavigatorByProfileID(context.Background(), 42)
+			if err != nil {
+				t.Fatalf("FindNavigatorByProfileID() error = %v", err)
+			}
+			if n == nil || n.ProfileID != 42 {
+				t.Fatalf("FindNavigatorByProfileID() = %v, want the navigator of the profile 42", n)
+			}
+			if (n.Location == nil) != (tt.want == nil) || (n.Location != nil && *n.Location != *tt.want) {
+				t.Errorf("FindNavigatorByProfileID() location = %v, want %v", n.Location, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileRepo_FindNavigatorByProfileID_NotFound(t *testing.T) {
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("FROM profile_navigators").WithArgs(42).WillReturnError(sql.ErrNoRows)
+
+	if _, err := repo.FindNavigatorByProfileID(context.Background(), 42); !errors.Is(err, usecases.ErrNotFound) {
+		t.Errorf("FindNavigatorByProfileID() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProfileRepo_FindNavigatorByProfileIDAndViewerID_NoLocation(t *testing.T) {
+	columns := []string{"id", "profile_id", "longitude", "latitude"}
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("FROM profile_navigators").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, 37.61, 55.75))
+	mock.ExpectQuery("FROM profile_navigators").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 42, nil, nil))
+
+	n, err := repo.FindNavigatorByProfileIDAndViewerID(context.Background(), 42, 1)
+	if err != nil {
+		t.Fatalf("FindNavigatorByProfileIDAndViewerID() error = %v", err)
+	}
+	if n != nil {
+		t.Errorf("FindNavigatorByProfileIDAndViewerID() = %+v, want no distance", n)
+	}
+}
+
+func TestProfileRepo_SelectMatchList(t *testing.T) {
+	columns := []string{"id", "id", "display_name", "last_online", "created_at", "url", "thumbnail_url"}
+	now := time.Now().UTC()
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("SELECT COUNT").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("LEFT JOIN LATERAL").WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(7, 2, "Anna", now, now, "static/2.webp", "static/2_thumb.webp").
+		AddRow(8, 3, "Maria", now, now, "static/3.webp", "").
+		AddRow(9, 4, "Olga", now, now, nil, nil))
+
+	response, err := repo.SelectMatchList(context.Background(), 1,
+		&entity.QueryParamsMatchList{Pagination: entity.Pagination{Page: 1, Size: 10}})
+	if err != nil {
+		t.Fatalf("SelectMatchList() error = %v", err)
+	}
+	if len(response.Content) != 3 || response.TotalItems != 3 {
+		t.Fatalf("SelectMatchList() = %d of %d matches, want 3 of 3", len(response.Content), response.TotalItems)
+	}
+	want := []*entity.ResponseImageProfile{
+		{Url: "static/2.webp", ThumbnailUrl: "static/2_thumb.webp"},
+		{Url: "static/3.webp", ThumbnailUrl: "static/3.webp"},
+		nil,
+	}
+	for i, m := range response.Content {
+		if (m.Image == nil) != (want[i] == nil) || (m.Image != nil && *m.Image != *want[i]) {
+			t.Errorf("match %d image = %+v, want %+v", m.ID, m.Image, want[i])
+		}
+		if !m.IsOnline {
+			t.Errorf("match %d is offline, want online", m.ID)
+		}
+	}
+}
+
+func TestProfileRepo_SelectMatchList_RowsError(t *testing.T) {
+	columns := []string{"id", "id", "display_name", "last_online", "created_at", "url", "thumbnail_url"}
+	now := time.Now().UTC()
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("SELECT COUNT").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("LEFT JOIN LATERAL").WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(7, 2, "Anna", now, now, nil, nil).
+		AddRow(8, 3, "Maria", now, now, nil, nil).
+		RowError(1, driver.ErrBadConn))
+
+	if _, err := repo.SelectMatchList(context.Background(), 1,
+		&entity.QueryParamsMatchList{Pagination: entity.Pagination{Page: 1, Size: 10}}); err == nil {
+		t.Error("SelectMatchList() error = nil, want the error of the rows")
+	}
+}
+
+func TestProfileRepo_SelectReviewList_ScanError(t *testing.T) {
+	columns := []string{"id", "profile_id", "message", "rating", "has_deleted", "has_edited", "created_at",
+		"updated_at", "display_name", "session_id"}
+	now := time.Now().UTC()
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("CURRENT_DATE").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("AVG").WillReturnRows(sqlmock.NewRows([]string{"avg_rating"}).AddRow(4.5))
+	mock.ExpectQuery("FROM profile_reviews").WithArgs(now, 9).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(8, 1, "good", 5, false, false, now, now, "Anna", "session").
+		AddRow(7, 1, "bad", "not a rating", false, false, now, now, "Anna", "session"))
+
+	qp := &entity.QueryParamsReviewList{
+		Pagination: entity.Pagination{Size: 10, Cursor: entity.EncodeCursor(&entity.Cursor{CreatedAt: now, ID: 9})},
+		ProfileID:  1,
+	}
+	if _, err := repo.SelectReviewList(context.Background(), qp); err == nil {
+		t.Error("SelectReviewList() error = nil, want the error of the scan")
+	}
+}
+
+func TestProfileRepo_AddTelegram_Conflict(t *testing.T) {
+	repo, mock := newMockProfileRepo(t)
+	mock.ExpectQuery("INSERT INTO profile_telegram").
+		WillReturnError(&pq.Error{Code: uniqueViolation, Constraint: "profile_telegram_telegram_id_key"})
+
+	_, err := repo.AddTelegram(context.Background(), &entity.TelegramProfile{ProfileID: 42, TelegramID: 1001})
+	if !errors.Is(err, usecases.ErrConflict) {
+		t.Errorf("AddTelegram() error = %v, want ErrConflict", err)
+	}
+}
@@ -0,0 +1,62 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/usecases"
+	"go.uber.org/zap"
+)
+
+type txKey struct{}
+
+// executor - the part of *sql.DB and *sql.Tx used by the repositories
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction started by Transactor if ctx carries one, otherwise the db itself
+func conn(ctx context.Context, db *sql.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type Transactor struct {
+	logger logger.Logger
+	db     *sql.DB
+}
+
+func NewTransactor(logger logger.Logger, db *sql.DB) usecases.Transactor {
+	return &Transactor{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// WithinTransaction runs fn in a transaction, repositories called with the ctx passed to fn take part in it.
+// A nested call joins the outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.logger.Debug("error func WithinTransaction, method BeginTx by path"+
+			" internal/storage/psql/transactor/transactor.go", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		t.logger.Debug("error func WithinTransaction, method Commit by path"+
+			" internal/storage/psql/transactor/transactor.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
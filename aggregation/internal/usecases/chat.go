@@ -0,0 +1,386 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const maxMessageLength = 4096
+
+var (
+	ErrMessageInvalid       = errors.New("message must not be empty or longer than 4096 characters")
+	ErrMatchRequired        = errors.New("messaging is allowed only after a mutual like")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("message not found")
+)
+
+type ChatRepo interface {
+	CheckIfMessagingAllowed(ctx context.Context, profileID uint64, participantID uint64) (bool, error)
+	AddConversation(ctx context.Context, c *entity.Conversation) (*entity.Conversation, error)
+	FindConversationByID(ctx context.Context, id uint64) (*entity.Conversation, bool, error)
+	SelectConversationList(ctx context.Context, profileID uint64,
+		qp *entity.QueryParamsConversationList) (*entity.ResponseListConversation, error)
+	AddMessage(ctx context.Context, m *entity.Message) (*entity.Message, error)
+	UpdateMessage(ctx context.Context, m *entity.Message) (*entity.Message, error)
+	DeleteMessage(ctx context.Context, m *entity.Message) (*entity.Message, error)
+	FindMessageByID(ctx context.Context, id uint64) (*entity.Message, bool, error)
+	SelectMessageList(ctx context.Context, qp *entity.QueryParamsMessageList) (*entity.ResponseListMessage, error)
+	ReadMessages(ctx context.Context, conversationID uint64, receiverID uint64, messageID uint64,
+		readAt time.Time) error
+	CountUnreadMessages(ctx context.Context, conversationID uint64, receiverID uint64) (uint64, error)
+}
+
+type ChatUseCases struct {
+	logger   logger.Logger
+	repo     ChatRepo
+	tx       Transactor
+	profiles *ProfileUseCases
+	Outbox   *OutboxUseCases
+	Hub      *entity.Hub
+}
+
+func NewChatUseCases(l logger.Logger, cr ChatRepo, tx Transactor, p *ProfileUseCases, o *OutboxUseCases,
+	h *entity.Hub) *ChatUseCases {
+	return &ChatUseCases{
+		logger:   l,
+		repo:     cr,
+		tx:       tx,
+		profiles: p,
+		Outbox:   o,
+		Hub:      h,
+	}
+}
+
+// CleanMessage trims the text of the message, ErrMessageInvalid means it is empty or too long
+func CleanMessage(message string) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" || len([]rune(message)) > maxMessageLength {
+		return "", ErrMessageInvalid
+	}
+	return message, nil
+}
+
+// SendMessage adds the message to the conversation of the sender and the receiver, the conversation is started
+// by the first message. Messaging is allowed between the matched profiles that haven't blocked each other.
+func (uc *ChatUseCases) SendMessage(
+	ctx context.Context, sender *entity.Profile, receiverID uint64, message string) (*entity.Message, error) {
+	message, err := CleanMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if sender.ID == receiverID {
+		return nil, ErrSelfAction
+	}
+	receiver, err := uc.profiles.findAvailableProfile(ctx, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	isAllowed, err := uc.CheckIfMessagingAllowed(ctx, sender.ID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAllowed {
+		return nil, ErrMatchRequired
+	}
+	if err := uc.profiles.UpdateLastOnline(ctx, sender.ID); err != nil {
+		return nil, err
+	}
+	var response *entity.Message
+	// the message and the telegram nudge are stored together or not at all
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		conversation, err := uc.AddConversation(ctx, &entity.Conversation{
+			ProfileID:     sender.ID,
+			ParticipantID: receiverID,
+			CreatedAt:     time.Now().UTC(),
+			UpdatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		messageDto := &entity.Message{
+			ConversationID: conversation.ID,
+			SenderID:       sender.ID,
+			ReceiverID:     receiverID,
+			Message:        message,
+			IsEdited:       false,
+			IsDeleted:      false,
+			CreatedAt:      time.Now().UTC(),
+			UpdatedAt:      time.Now().UTC(),
+		}
+		response, err = uc.AddMessage(ctx, messageDto)
+		if err != nil {
+			return err
+		}
+		return uc.notifyOfflineReceiver(ctx, sender, receiver, response)
+	})
+	if err != nil {
+		uc.logger.Debug("error func SendMessage, method WithinTransaction by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	uc.Hub.Publish(&entity.Event{
+		Type:      entity.EventTypeMessage,
+		ProfileID: receiverID,
+		Payload:   response,
+	})
+	return response, nil
+}
+
+// notifyOfflineReceiver queues a telegram nudge for an offline receiver once per batch of unread messages
+func (uc *ChatUseCases) notifyOfflineReceiver(
+	ctx context.Context, sender *entity.Profile, receiver *entity.Profile, m *entity.Message) error {
+	elapsed := time.Since(receiver.LastOnline)
+	if elapsed.Minutes() < 5 {
+		return nil
+	}
+	unreadCount, err := uc.CountUnreadMessages(ctx, m.ConversationID, receiver.ID)
+	if err != nil {
+		return err
+	}
+	if unreadCount != 1 {
+		return nil
+	}
+	telegramBySender, err := uc.profiles.FindTelegramByProfileID(ctx, sender.ID)
+	if err != nil {
+		uc.logger.Debug("error func notifyOfflineReceiver, method FindTelegramByProfileID by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil
+	}
+	telegramByReceiver, err := uc.profiles.FindTelegramByProfileID(ctx, receiver.ID)
+	if err != nil {
+		uc.logger.Debug("error func notifyOfflineReceiver, method FindTelegramByProfileID by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil
+	}
+	return uc.Outbox.AddNotification(ctx, receiver.ID, &entity.Content{
+		ChatID:   telegramByReceiver.ChatID,
+		Type:     entity.EventTypeMessage,
+		Message:  fmt.Sprintf("Новое сообщение от @%s", telegramBySender.UserName),
+		Username: telegramBySender.UserName,
+	})
+}
+
+func (uc *ChatUseCases) CheckIfMessagingAllowed(
+	ctx context.Context, profileID uint64, participantID uint64) (bool, error) {
+	isAllowed, err := uc.repo.CheckIfMessagingAllowed(ctx, profileID, participantID)
+	if err != nil {
+		uc.logger.Debug("error func CheckIfMessagingAllowed, method CheckIfMessagingAllowed by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return false, err
+	}
+	return isAllowed, nil
+}
+
+func (uc *ChatUseCases) AddConversation(
+	ctx context.Context, c *entity.Conversation) (*entity.Conversation, error) {
+	response, err := uc.repo.AddConversation(ctx, c)
+	if err != nil {
+		uc.logger.Debug("error func AddConversation, method AddConversation by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ChatUseCases) FindConversationByID(ctx context.Context, id uint64) (*entity.Conversation, bool, error) {
+	response, isExist, err := uc.repo.FindConversationByID(ctx, id)
+	if err != nil {
+		uc.logger.Debug("error func FindConversationByID, method FindConversationByID by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
+
+func (uc *ChatUseCases) SelectConversationList(ctx context.Context, profileID uint64,
+	qp *entity.QueryParamsConversationList) (*entity.ResponseListConversation, error) {
+	response, err := uc.repo.SelectConversationList(ctx, profileID, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectConversationList, method SelectConversationList by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ChatUseCases) AddMessage(ctx context.Context, m *entity.Message) (*entity.Message, error) {
+	response, err := uc.repo.AddMessage(ctx, m)
+	if err != nil {
+		uc.logger.Debug("error func AddMessage, method AddMessage by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// EditMessage changes the text of the message of the viewer, the profiles must still be matched
+func (uc *ChatUseCases) EditMessage(
+	ctx context.Context, viewer *entity.Profile, messageID uint64, message string) (*entity.Message, error) {
+	message, err := CleanMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	m, err := uc.findOwnMessage(ctx, viewer, messageID)
+	if err != nil {
+		return nil, err
+	}
+	isAllowed, err := uc.CheckIfMessagingAllowed(ctx, viewer.ID, m.ReceiverID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAllowed {
+		return nil, ErrMatchRequired
+	}
+	if err := uc.profiles.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	messageDto := &entity.Message{
+		ID:        m.ID,
+		Message:   message,
+		IsEdited:  true,
+		UpdatedAt: time.Now().UTC(),
+	}
+	response, err := uc.repo.UpdateMessage(ctx, messageDto)
+	if err != nil {
+		uc.logger.Debug("error func EditMessage, method UpdateMessage by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	uc.Hub.Publish(&entity.Event{
+		Type:      entity.EventTypeMessage,
+		ProfileID: response.ReceiverID,
+		Payload:   response,
+	})
+	return response, nil
+}
+
+// DeleteMessage deletes the message of the viewer
+func (uc *ChatUseCases) DeleteMessage(
+	ctx context.Context, viewer *entity.Profile, messageID uint64) (*entity.Message, error) {
+	m, err := uc.findOwnMessage(ctx, viewer, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.profiles.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	messageDto := &entity.Message{
+		ID:        m.ID,
+		IsDeleted: true,
+		UpdatedAt: time.Now().UTC(),
+	}
+	response, err := uc.repo.DeleteMessage(ctx, messageDto)
+	if err != nil {
+		uc.logger.Debug("error func DeleteMessage, method DeleteMessage by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	uc.Hub.Publish(&entity.Event{
+		Type:      entity.EventTypeMessage,
+		ProfileID: response.ReceiverID,
+		Payload:   response,
+	})
+	return response, nil
+}
+
+func (uc *ChatUseCases) FindMessageByID(ctx context.Context, id uint64) (*entity.Message, bool, error) {
+	response, isExist, err := uc.repo.FindMessageByID(ctx, id)
+	if err != nil {
+		uc.logger.Debug("error func FindMessageByID, method FindMessageByID by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
+
+// GetMessageList returns the page of the messages of the conversation the viewer takes part in
+func (uc *ChatUseCases) GetMessageList(ctx context.Context, viewer *entity.Profile,
+	qp *entity.QueryParamsMessageList) (*entity.ResponseListMessage, error) {
+	if _, err := uc.findConversation(ctx, viewer, qp.ConversationID); err != nil {
+		return nil, err
+	}
+	if err := uc.profiles.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	response, err := uc.repo.SelectMessageList(ctx, qp)
+	if err != nil {
+		uc.logger.Debug("error func GetMessageList, method SelectMessageList by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// ReadMessages marks the messages to the viewer up to the given one as read and returns how many are left unread
+func (uc *ChatUseCases) ReadMessages(ctx context.Context, viewer *entity.Profile, conversationID uint64,
+	messageID uint64) (*entity.ResponseReadMessage, error) {
+	if _, err := uc.findConversation(ctx, viewer, conversationID); err != nil {
+		return nil, err
+	}
+	if err := uc.profiles.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	err := uc.repo.ReadMessages(ctx, conversationID, viewer.ID, messageID, time.Now().UTC())
+	if err != nil {
+		uc.logger.Debug("error func ReadMessages, method ReadMessages by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return nil, err
+	}
+	unreadCount, err := uc.CountUnreadMessages(ctx, conversationID, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.ResponseReadMessage{
+		ConversationID: conversationID,
+		UnreadCount:    unreadCount,
+	}, nil
+}
+
+func (uc *ChatUseCases) CountUnreadMessages(
+	ctx context.Context, conversationID uint64, receiverID uint64) (uint64, error) {
+	response, err := uc.repo.CountUnreadMessages(ctx, conversationID, receiverID)
+	if err != nil {
+		uc.logger.Debug("error func CountUnreadMessages, method CountUnreadMessages by path"+
+			" internal/usecases/chat/chat.go", zap.Error(err))
+		return 0, err
+	}
+	return response, nil
+}
+
+// findConversation returns the conversation if the viewer takes part in it
+func (uc *ChatUseCases) findConversation(
+	ctx context.Context, viewer *entity.Profile, conversationID uint64) (*entity.Conversation, error) {
+	c, isExist, err := uc.FindConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !isExist {
+		return nil, ErrConversationNotFound
+	}
+	if c.ProfileID != viewer.ID && c.ParticipantID != viewer.ID {
+		return nil, ErrForbidden
+	}
+	return c, nil
+}
+
+// findOwnMessage returns the message of the viewer that has not been deleted yet
+func (uc *ChatUseCases) findOwnMessage(
+	ctx context.Context, viewer *entity.Profile, messageID uint64) (*entity.Message, error) {
+	m, isExist, err := uc.FindMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if !isExist || m.IsDeleted {
+		return nil, ErrMessageNotFound
+	}
+	if m.SenderID != viewer.ID {
+		return nil, ErrForbidden
+	}
+	return m, nil
+}
@@ -0,0 +1,251 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTransactor runs the function without a transaction
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeChatRepo struct {
+	ChatRepo
+	isAllowed    bool
+	unreadCount  uint64
+	messages     []*entity.Message
+	message      *entity.Message
+	conversation *entity.Conversation
+	readUpTo     uint64
+}
+
+func (r *fakeChatRepo) CheckIfMessagingAllowed(_ context.Context, _ uint64, _ uint64) (bool, error) {
+	return r.isAllowed, nil
+}
+
+func (r *fakeChatRepo) AddConversation(_ context.Context, c *entity.Conversation) (*entity.Conversation, error) {
+	c.ID = 10
+	return c, nil
+}
+
+func (r *fakeChatRepo) AddMessage(_ context.Context, m *entity.Message) (*entity.Message, error) {
+	m.ID = uint64(len(r.messages) + 1)
+	r.messages = append(r.messages, m)
+	return m, nil
+}
+
+func (r *fakeChatRepo) CountUnreadMessages(_ context.Context, _ uint64, _ uint64) (uint64, error) {
+	return r.unreadCount, nil
+}
+
+func (r *fakeChatRepo) FindMessageByID(_ context.Context, id uint64) (*entity.Message, bool, error) {
+	if r.message == nil || r.message.ID != id {
+		return nil, false, nil
+	}
+	return r.message, true, nil
+}
+
+func (r *fakeChatRepo) UpdateMessage(_ context.Context, m *entity.Message) (*entity.Message, error) {
+	stored := *r.message
+	stored.Message, stored.IsEdited, stored.UpdatedAt = m.Message, m.IsEdited, m.UpdatedAt
+	r.messages = append(r.messages, &stored)
+	return &stored, nil
+}
+
+func (r *fakeChatRepo) DeleteMessage(_ context.Context, m *entity.Message) (*entity.Message, error) {
+	stored := *r.message
+	stored.IsDeleted, stored.UpdatedAt = m.IsDeleted, m.UpdatedAt
+	r.messages = append(r.messages, &stored)
+	return &stored, nil
+}
+
+func (r *fakeChatRepo) FindConversationByID(_ context.Context, id uint64) (*entity.Conversation, bool, error) {
+	if r.conversation == nil || r.conversation.ID != id {
+		return nil, false, nil
+	}
+	return r.conversation, true, nil
+}
+
+func (r *fakeChatRepo) ReadMessages(_ context.Context, _ uint64, _ uint64, messageID uint64, _ time.Time) error {
+	r.readUpTo = messageID
+	return nil
+}
+
+func TestChatUseCases_SendMessage(t *testing.T) {
+	offline := time.Now().UTC().Add(-time.Hour)
+	online := time.Now().UTC()
+	tests := []struct {
+		name        string
+		receiverID  uint64
+		message     string
+		receiver    *entity.Profile
+		isAllowed   bool
+		unreadCount uint64
+		wantErr     error
+		wantNudge   bool
+	}{
+		{"offline receiver", 2, " hello ", &entity.Profile{ID: 2, LastOnline: offline}, true, 1, nil, true},
+		{"offline receiver with unread messages", 2, "hello", &entity.Profile{ID: 2, LastOnline: offline},
+			true, 2, nil, false},
+		{"online receiver", 2, "hello", &entity.Profile{ID: 2, LastOnline: online}, true, 1, nil, false},
+		{"empty message", 2, "  ", &entity.Profile{ID: 2}, true, 1, ErrMessageInvalid, false},
+		{"long message", 2, strings.Repeat("я", maxMessageLength+1), &entity.Profile{ID: 2}, true, 1,
+			ErrMessageInvalid, false},
+		{"self", 1, "hello", &entity.Profile{ID: 2}, true, 1, ErrSelfAction, false},
+		{"no receiver", 3, "hello", &entity.Profile{ID: 2}, true, 1, ErrProfileNotFound, false},
+		{"blocked receiver", 2, "hello", &entity.Profile{ID: 2, IsBlocked: true}, true, 1, ErrProfileUnavailable,
+			false},
+		{"no match", 2, "hello", &entity.Profile{ID: 2}, false, 1, ErrMatchRequired, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &fakeProfileRepo{profiles: map[uint64]*entity.Profile{tt.receiver.ID: tt.receiver}}
+			cr := &fakeChatRepo{isAllowed: tt.isAllowed, unreadCount: tt.unreadCount}
+			or := &fakeOutboxRepo{}
+			hub := entity.NewHub()
+			events := hub.Subscribe(1)
+			puc := newTestProfileUseCases(pr)
+			ouc := NewOutboxUseCases(zap.NewNop(), or, nil)
+			uc := NewChatUseCases(zap.NewNop(), cr, fakeTransactor{}, puc, ouc, hub)
+			sender := &entity.Profile{ID: 1}
+			got, err := uc.SendMessage(context.Background(), sender, tt.receiverID, tt.message)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SendMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(cr.messages) != 0 || len(pr.lastOnline) != 0 {
+					t.Errorf("message stored or sender updated on an error")
+				}
+				return
+			}
+			if got.ConversationID != 10 || got.SenderID != sender.ID || got.ReceiverID != tt.receiverID ||
+				got.Message != "hello" {
+				t.Errorf("SendMessage() = %+v", got)
+			}
+			if len(pr.lastOnline) != 1 || pr.lastOnline[0] != sender.ID {
+				t.Errorf("last online updated for %v, want [%d]", pr.lastOnline, sender.ID)
+			}
+			if gotNudge := len(or.added) == 1; gotNudge != tt.wantNudge {
+				t.Errorf("nudge queued = %v, want %v", gotNudge, tt.wantNudge)
+			}
+			select {
+			case e := <-events.Events:
+				if e.Type != entity.EventTypeMessage || e.ProfileID != tt.receiverID || e.Payload != got {
+					t.Errorf("published %+v", e)
+				}
+			default:
+				t.Error("message event was not published")
+			}
+		})
+	}
+}
+
+func TestChatUseCases_EditMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		messageID uint64
+		text      string
+		message   *entity.Message
+		isAllowed bool
+		wantErr   error
+	}{
+		{"edited", 7, " hi ", &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2}, true, nil},
+		{"empty", 7, " ", &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2}, true, ErrMessageInvalid},
+		{"not found", 8, "hi", &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2}, true, ErrMessageNotFound},
+		{"deleted", 7, "hi", &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2, IsDeleted: true}, true,
+			ErrMessageNotFound},
+		{"another sender", 7, "hi", &entity.Message{ID: 7, SenderID: 2, ReceiverID: 1}, true, ErrForbidden},
+		{"no match", 7, "hi", &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2}, false, ErrMatchRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &fakeProfileRepo{}
+			cr := &fakeChatRepo{isAllowed: tt.isAllowed, message: tt.message}
+			hub := entity.NewHub()
+			events := hub.Subscribe(2)
+			uc := NewChatUseCases(zap.NewNop(), cr, fakeTransactor{}, newTestProfileUseCases(pr), nil, hub)
+			got, err := uc.EditMessage(context.Background(), &entity.Profile{ID: 1}, tt.messageID, tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EditMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(cr.messages) != 0 || len(pr.lastOnline) != 0 {
+					t.Errorf("message stored or sender updated on an error")
+				}
+				return
+			}
+			if got.Message != "hi" || !got.IsEdited || len(pr.lastOnline) != 1 {
+				t.Errorf("EditMessage() = %+v, last online updated for %v", got, pr.lastOnline)
+			}
+			select {
+			case e := <-events.Events:
+				if e.Type != entity.EventTypeMessage || e.Payload != got {
+					t.Errorf("published %+v", e)
+				}
+			default:
+				t.Error("message event was not published")
+			}
+		})
+	}
+}
+
+func TestChatUseCases_DeleteMessage(t *testing.T) {
+	cr := &fakeChatRepo{message: &entity.Message{ID: 7, SenderID: 1, ReceiverID: 2}}
+	uc := NewChatUseCases(zap.NewNop(), cr, fakeTransactor{}, newTestProfileUseCases(&fakeProfileRepo{}), nil,
+		entity.NewHub())
+	if _, err := uc.DeleteMessage(context.Background(), &entity.Profile{ID: 2}, 7); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("DeleteMessage() of another sender error = %v, want %v", err, ErrForbidden)
+	}
+	got, err := uc.DeleteMessage(context.Background(), &entity.Profile{ID: 1}, 7)
+	if err != nil {
+		t.Fatalf("DeleteMessage() error = %v", err)
+	}
+	if !got.IsDeleted || got.ReceiverID != 2 {
+		t.Errorf("DeleteMessage() = %+v", got)
+	}
+}
+
+func TestChatUseCases_ReadMessages(t *testing.T) {
+	tests := []struct {
+		name           string
+		conversationID uint64
+		viewerID       uint64
+		wantErr        error
+	}{
+		{"participant", 10, 2, nil},
+		{"starter", 10, 1, nil},
+		{"another profile", 10, 3, ErrForbidden},
+		{"not found", 11, 2, ErrConversationNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &fakeChatRepo{
+				conversation: &entity.Conversation{ID: 10, ProfileID: 1, ParticipantID: 2},
+				unreadCount:  3,
+			}
+			uc := NewChatUseCases(zap.NewNop(), cr, fakeTransactor{}, newTestProfileUseCases(&fakeProfileRepo{}),
+				nil, entity.NewHub())
+			got, err := uc.ReadMessages(context.Background(), &entity.Profile{ID: tt.viewerID}, tt.conversationID, 42)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadMessages() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if cr.readUpTo != 0 {
+					t.Errorf("messages read up to %d on an error", cr.readUpTo)
+				}
+				return
+			}
+			if cr.readUpTo != 42 || got.ConversationID != 10 || got.UnreadCount != 3 {
+				t.Errorf("ReadMessages() = %+v, read up to %d", got, cr.readUpTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package usecases
+
+import "errors"
+
+// The repositories translate the storage errors into these ones, so the use cases and the handlers
+// don't depend on the database driver
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
@@ -10,33 +10,56 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"strings"
+	"sync"
+	"time"
 )
 
+// tokenRefreshSkew - the service account token is renewed this long before it expires
+const tokenRefreshSkew = 30 * time.Second
+
+// IdentityUseCases is a long-lived keycloak admin client. It holds one gocloak client and the service account
+// token, which is shared by all the operations and renewed before it expires. Safe for concurrent use.
 type IdentityUseCases struct {
 	BaseUrl      string
 	Realm        string
 	ClientId     string
 	ClientSecret string
 	logger       logger.Logger
+	// actionsLifespan - lifespan of the execute-actions email links in seconds
+	actionsLifespan int
+	client          *gocloak.GoCloak
+	mu              sync.Mutex
+	token           *gocloak.JWT
+	expiresAt       time.Time
 }
 
 func NewIdentity(config *config.Config, l logger.Logger) *IdentityUseCases {
 	return &IdentityUseCases{
-		BaseUrl:      config.BaseUrl,
-		Realm:        config.Realm,
-		ClientId:     config.ClientId,
-		ClientSecret: config.ClientSecret,
-		logger:       l,
+		BaseUrl:         config.BaseUrl,
+		Realm:           config.Realm,
+		ClientId:        config.ClientId,
+		ClientSecret:    config.ClientSecret,
+		logger:          l,
+		actionsLifespan: int(config.ActionsEmailLifespan.Seconds()),
+		client:          gocloak.NewClient(config.BaseUrl),
 	}
 }
 
+// loginRestApiClient returns the cached service account token, logging in again only when it is about to expire
 func (i *IdentityUseCases) loginRestApiClient(ctx context.Context) (*gocloak.JWT, error) {
-	client := gocloak.NewClient(i.BaseUrl)
-	token, err := client.LoginClient(ctx, i.ClientId, i.ClientSecret, i.Realm)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.token != nil && time.Now().Before(i.expiresAt) {
+		return i.token, nil
+	}
+	issuedAt := time.Now()
+	token, err := i.client.LoginClient(ctx, i.ClientId, i.ClientSecret, i.Realm)
 	if err != nil {
 		i.logger.Debug("error unable to login the rest client by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to login the rest client")
 	}
+	i.token = token
+	i.expiresAt = issuedAt.Add(time.Duration(token.ExpiresIn)*time.Second - tokenRefreshSkew)
 	return token, nil
 }
 
@@ -45,42 +68,35 @@ func (i *IdentityUseCases) CreateUser(ctx context.Context, user gocloak.User, pa
 	if err != nil {
 		return nil, err
 	}
-	client := gocloak.NewClient(i.BaseUrl)
-	isUniqueMobileNumber, err := i.validateMobileNumbers(ctx, (*user.Attributes)["mobileNumber"], token, client)
-	if err != nil {
-		i.logger.Debug("error get users for validation mobile number is invalid by path"+
-			" entity/identity/identity.go", zap.Error(err))
-		return nil, errors.Wrap(err, "get users for validation mobile number is invalid")
-	}
-	if !isUniqueMobileNumber {
-		i.logger.Debug("error mobile number must be unique by path entity/identity/identity.go", zap.Error(err))
-		return nil, errors.New("mobile number must be unique")
+	if err := i.validateUniqueness(ctx, user, token); err != nil {
+		i.logger.Debug("error validate uniqueness of the user by path entity/identity/identity.go", zap.Error(err))
+		return nil, err
 	}
-	userId, err := client.CreateUser(ctx, token.AccessToken, i.Realm, user)
+	userId, err := i.client.CreateUser(ctx, token.AccessToken, i.Realm, user)
 	if err != nil {
 		i.logger.Debug("error unable to create the user by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to create the user")
 	}
-	err = client.SetPassword(ctx, token.AccessToken, userId, i.Realm, password, false)
+	err = i.client.SetPassword(ctx, token.AccessToken, userId, i.Realm, password, false)
 	if err != nil {
 		i.logger.Debug("error unable to set the password for the user by path"+
 			" entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to set the password for the user")
 	}
 	var roleNameLowerCase = strings.ToLower(role)
-	roleKeycloak, err := client.GetRealmRole(ctx, token.AccessToken, i.Realm, roleNameLowerCase)
+	roleKeycloak, err := i.client.GetRealmRole(ctx, token.AccessToken, i.Realm, roleNameLowerCase)
 	if err != nil {
 		i.logger.Debug("error unable to get role by name by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, fmt.Sprintf("unable to get role by name: '%v'", roleNameLowerCase))
 	}
-	err = client.AddRealmRoleToUser(ctx, token.AccessToken, i.Realm, userId, []gocloak.Role{
+	err = i.client.AddRealmRoleToUser(ctx, token.AccessToken, i.Realm, userId, []gocloak.Role{
 		*roleKeycloak,
 	})
 	if err != nil {
 		i.logger.Debug("error unable to add a realm role to user by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to add a realm role to user")
 	}
-	userKeycloak, err := client.GetUserByID(ctx, token.AccessToken, i.Realm, userId)
+	userKeycloak, err := i.client.GetUserByID(ctx, token.AccessToken, i.Realm, userId)
 	if err != nil {
 		i.logger.Debug("error unable to get recently created user by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to get recently created user")
@@ -93,18 +109,11 @@ func (i *IdentityUseCases) UpdateUser(ctx context.Context, user gocloak.User) (*
 	if err != nil {
 		return nil, err
 	}
-	client := gocloak.NewClient(i.BaseUrl)
-	isUniqueMobileNumber, err := i.validateMobileNumbers(ctx, (*user.Attributes)["mobileNumber"], token, client)
-	if err != nil {
-		i.logger.Debug("error get users for validation mobile number is invalid by path"+
-			" entity/identity/identity.go", zap.Error(err))
-		return nil, errors.Wrap(err, "get users for validation mobile number is invalid")
-	}
-	if !isUniqueMobileNumber {
-		i.logger.Debug("error mobile number must be unique by path entity/identity/identity.go", zap.Error(err))
-		return nil, errors.New("mobile number must be unique")
+	if err := i.validateUniqueness(ctx, user, token); err != nil {
+		i.logger.Debug("error validate uniqueness of the user by path entity/identity/identity.go", zap.Error(err))
+		return nil, err
 	}
-	err = client.UpdateUser(ctx, token.AccessToken, i.Realm, user)
+	err = i.client.UpdateUser(ctx, token.AccessToken, i.Realm, user)
 	if err != nil {
 		i.logger.Debug("error unable to update the user by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to update the user")
@@ -112,7 +121,7 @@ func (i *IdentityUseCases) UpdateUser(ctx context.Context, user gocloak.User) (*
 	if user.ID == nil {
 		return nil, errors.New("user ID is nil")
 	}
-	userKeycloak, err := client.GetUserByID(ctx, token.AccessToken, i.Realm, *user.ID)
+	userKeycloak, err := i.client.GetUserByID(ctx, token.AccessToken, i.Realm, *user.ID)
 	if err != nil {
 		i.logger.Debug("error unable to get recently created user by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to get recently created user")
@@ -123,10 +132,9 @@ func (i *IdentityUseCases) UpdateUser(ctx context.Context, user gocloak.User) (*
 func (i *IdentityUseCases) DeleteUser(ctx context.Context, user gocloak.User) error {
 	token, err := i.loginRestApiClient(ctx)
 	if err != nil {
-		return nil
+		return err
 	}
-	client := gocloak.NewClient(i.BaseUrl)
-	err = client.DeleteUser(ctx, token.AccessToken, i.Realm, *user.ID)
+	err = i.client.DeleteUser(ctx, token.AccessToken, i.Realm, *user.ID)
 	if err != nil {
 		i.logger.Debug("error unable to delete the user by path entity/identity/identity.go", zap.Error(err))
 		return errors.Wrap(err, "unable to delete the user")
@@ -135,8 +143,7 @@ func (i *IdentityUseCases) DeleteUser(ctx context.Context, user gocloak.User) er
 }
 
 func (i *IdentityUseCases) RetrospectToken(ctx context.Context, accessToken string) (*gocloak.IntroSpectTokenResult, error) {
-	client := gocloak.NewClient(i.BaseUrl)
-	rptResult, err := client.RetrospectToken(ctx, accessToken, i.ClientId, i.ClientSecret, i.Realm)
+	rptResult, err := i.client.RetrospectToken(ctx, accessToken, i.ClientId, i.ClientSecret, i.Realm)
 	if err != nil {
 		i.logger.Debug("error unable to retrospect token by path entity/identity/identity.go", zap.Error(err))
 		return nil, errors.Wrap(err, "unable to retrospect token")
@@ -149,39 +156,190 @@ func (i *IdentityUseCases) GetUserList(ctx context.Context, query entity.QueryPa
 	if err != nil {
 		return nil, err
 	}
-	client := gocloak.NewClient(i.BaseUrl)
-	users, err := client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{Search: &query.Search})
+	users, err := i.client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{Search: &query.Search})
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (i *IdentityUseCases) validateMobileNumbers(ctx context.Context, mobileNumberList []string, token *gocloak.JWT, client *gocloak.GoCloak) (bool, error) {
-	uniqueMap := make(map[string]bool) // Для хранения уникальности каждого номера
-	users, err := client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{})
+func (i *IdentityUseCases) GetUserByID(ctx context.Context, userID string) (*gocloak.User, error) {
+	token, err := i.loginRestApiClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := i.client.GetUserByID(ctx, token.AccessToken, i.Realm, userID)
+	if err != nil {
+		i.logger.Debug("error unable to get the user by path entity/identity/identity.go", zap.Error(err))
+		return nil, errors.Wrap(err, "unable to get the user")
+	}
+	return user, nil
+}
+
+// FindUserByEmail returns nil without an error when there is no user with the email
+func (i *IdentityUseCases) FindUserByEmail(ctx context.Context, email string) (*gocloak.User, error) {
+	token, err := i.loginRestApiClient(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	// Инициализируем карту уникальности
-	for _, mobileNumber := range mobileNumberList {
-		uniqueMap[mobileNumber] = true
+	users, err := i.client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{
+		Email: gocloak.StringP(email),
+		Exact: gocloak.BoolP(true),
+	})
+	if err != nil {
+		i.logger.Debug("error unable to find the user by email by path entity/identity/identity.go", zap.Error(err))
+		return nil, errors.Wrap(err, "unable to find the user by email")
 	}
-	// Проверяем существующих пользователей
-	for _, user := range users {
-		mobileAttr, exists := (*user.Attributes)["mobileNumber"]
-		if exists && len(mobileAttr) > 0 {
-			// Если номер встречается у существующего пользователя, устанавливаем значение в карте уникальности как false
-			if _, isInList := uniqueMap[mobileAttr[0]]; isInList {
-				uniqueMap[mobileAttr[0]] = false
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return users[0], nil
+}
+
+// ExecuteActionsEmail sends the user an email with a link to perform the required actions, e.g. UPDATE_PASSWORD
+func (i *IdentityUseCases) ExecuteActionsEmail(ctx context.Context, userID string, actions []string) error {
+	token, err := i.loginRestApiClient(ctx)
+	if err != nil {
+		return err
+	}
+	err = i.client.ExecuteActionsEmail(ctx, token.AccessToken, i.Realm, gocloak.ExecuteActionsEmail{
+		UserID:   gocloak.StringP(userID),
+		Lifespan: gocloak.IntP(i.actionsLifespan),
+		Actions:  &actions,
+	})
+	if err != nil {
+		i.logger.Debug("error unable to execute actions email by path entity/identity/identity.go", zap.Error(err))
+		return errors.Wrap(err, "unable to execute actions email")
+	}
+	return nil
+}
+
+func (i *IdentityUseCases) SendVerifyEmail(ctx context.Context, userID string) error {
+	token, err := i.loginRestApiClient(ctx)
+	if err != nil {
+		return err
+	}
+	err = i.client.SendVerifyEmail(ctx, token.AccessToken, userID, i.Realm)
+	if err != nil {
+		i.logger.Debug("error unable to send verify email by path entity/identity/identity.go", zap.Error(err))
+		return errors.Wrap(err, "unable to send verify email")
+	}
+	return nil
+}
+
+// LogoutAllSessions ends every keycloak session of the user, its access tokens fail the next introspection
+func (i *IdentityUseCases) LogoutAllSessions(ctx context.Context, userID string) error {
+	token, err := i.loginRestApiClient(ctx)
+	if err != nil {
+		return err
+	}
+	err = i.client.LogoutAllSessions(ctx, token.AccessToken, i.Realm, userID)
+	if err != nil {
+		i.logger.Debug("error unable to logout all sessions by path entity/identity/identity.go", zap.Error(err))
+		return errors.Wrap(err, "unable to logout all sessions")
+	}
+	return nil
+}
+
+// validateUniqueness checks that the mobile number and the email of the user aren't taken by another user.
+// The user itself is excluded, so an update with unchanged values passes. The number is also looked up in
+// the legacy forms, which stay in keycloak until NormalizeMobileNumbers rewrites them.
+func (i *IdentityUseCases) validateUniqueness(ctx context.Context, user gocloak.User, token *gocloak.JWT) error {
+	if user.Attributes != nil {
+		for _, mobileNumber := range (*user.Attributes)["mobileNumber"] {
+			for _, form := range append([]string{mobileNumber}, legacyMobileNumbers(mobileNumber)...) {
+				users, err := i.client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{
+					Q:     gocloak.StringP("mobileNumber:" + form),
+					Exact: gocloak.BoolP(true),
+				})
+				if err != nil {
+					return errors.Wrap(err, "get users for validation mobile number is invalid")
+				}
+				if containsOtherUser(users, user.ID) {
+					return ErrMobileNumberNotUnique
+				}
 			}
 		}
 	}
-	// Проверяем, все ли значения в карте уникальности равны true
-	for _, isUnique := range uniqueMap {
-		if !isUnique {
-			return false, nil
+	if user.Email != nil && *user.Email != "" {
+		users, err := i.client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{
+			Email: user.Email,
+			Exact: gocloak.BoolP(true),
+		})
+		if err != nil {
+			return errors.Wrap(err, "get users for validation email is invalid")
+		}
+		if containsOtherUser(users, user.ID) {
+			return ErrEmailNotUnique
+		}
+	}
+	return nil
+}
+
+// NormalizeMobileNumbers rewrites the mobile numbers stored before the numbers were normalized to E.164 and
+// returns how many users were updated. A number that can't be normalized is left as it is and logged, as is
+// a number that turns out to be taken by another user.
+func (i *IdentityUseCases) NormalizeMobileNumbers(ctx context.Context) (int, error) {
+	const pageSize = 100
+	owners := make(map[string]string)
+	updated := 0
+	for first := 0; ; first += pageSize {
+		token, err := i.loginRestApiClient(ctx)
+		if err != nil {
+			return updated, err
+		}
+		users, err := i.client.GetUsers(ctx, token.AccessToken, i.Realm, gocloak.GetUsersParams{
+			First:               gocloak.IntP(first),
+			Max:                 gocloak.IntP(pageSize),
+			BriefRepresentation: gocloak.BoolP(false),
+		})
+		if err != nil {
+			i.logger.Debug("error unable to get users by path entity/identity/identity.go", zap.Error(err))
+			return updated, errors.Wrap(err, "unable to get users")
+		}
+		for _, user := range users {
+			if user.ID == nil || user.Attributes == nil {
+				continue
+			}
+			mobileNumbers := (*user.Attributes)["mobileNumber"]
+			isChanged := false
+			for n, mobileNumber := range mobileNumbers {
+				normalized, err := normalizeMobileNumber(mobileNumber)
+				if err != nil {
+					i.logger.Info("mobile number can't be normalized", zap.String("userId", *user.ID),
+						zap.String("mobileNumber", mobileNumber))
+					continue
+				}
+				if owner, ok := owners[normalized]; ok && owner != *user.ID {
+					i.logger.Info("mobile number is not unique", zap.String("userId", *user.ID),
+						zap.String("ownerId", owner), zap.String("mobileNumber", normalized))
+				}
+				owners[normalized] = *user.ID
+				if normalized != mobileNumber {
+					mobileNumbers[n] = normalized
+					isChanged = true
+				}
+			}
+			if !isChanged {
+				continue
+			}
+			if err := i.client.UpdateUser(ctx, token.AccessToken, i.Realm, *user); err != nil {
+				i.logger.Debug("error unable to update the user by path entity/identity/identity.go", zap.Error(err))
+				return updated, errors.Wrap(err, "unable to update the user")
+			}
+			updated++
+		}
+		if len(users) < pageSize {
+			return updated, nil
+		}
+	}
+}
+
+func containsOtherUser(users []*gocloak.User, id *string) bool {
+	for _, u := range users {
+		if id == nil || u.ID == nil || *u.ID != *id {
+			return true
 		}
 	}
-	return true, nil
+	return false
 }
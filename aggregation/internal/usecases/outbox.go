@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"context"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	outboxPollInterval = 2 * time.Second
+	outboxBatchSize    = 20
+	outboxMaxAttempts  = 8
+	outboxBaseBackoff  = 10 * time.Second
+	outboxMaxBackoff   = time.Hour
+	outboxSendTimeout  = 10 * time.Second
+	// outboxLease is longer than sending the whole batch, so a claimed notification isn't sent twice
+	outboxLease = 2 * outboxBatchSize * outboxSendTimeout
+)
+
+type OutboxRepo interface {
+	Add(ctx context.Context, o *entity.NotificationOutbox) (*entity.NotificationOutbox, error)
+	ClaimPending(ctx context.Context, limit uint64, leaseUntil time.Time) ([]*entity.NotificationOutbox, error)
+	MarkSent(ctx context.Context, id uint64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, o *entity.NotificationOutbox) error
+	Replay(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error)
+	FindByID(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error)
+	SelectList(ctx context.Context, qp *entity.QueryParamsOutboxList) (*entity.ResponseListOutbox, error)
+}
+
+// Notifier delivers a notification to the user, e.g. through the telegram bot
+type Notifier interface {
+	Notify(ctx context.Context, c *entity.Content) error
+}
+
+type OutboxUseCases struct {
+	logger   logger.Logger
+	repo     OutboxRepo
+	notifier Notifier
+}
+
+func NewOutboxUseCases(l logger.Logger, or OutboxRepo, n Notifier) *OutboxUseCases {
+	return &OutboxUseCases{
+		logger:   l,
+		repo:     or,
+		notifier: n,
+	}
+}
+
+// AddNotification queues the notification; call it with the ctx of the transaction that changes the domain
+// so that both are committed or rolled back together. Profiles without a telegram chat are skipped.
+func (uc *OutboxUseCases) AddNotification(ctx context.Context, profileID uint64, c *entity.Content) error {
+	if c == nil || c.ChatID == 0 {
+		return nil
+	}
+	o := &entity.NotificationOutbox{
+		ProfileID:     profileID,
+		Type:          c.Type,
+		Payload:       c,
+		Status:        entity.OutboxStatusPending,
+		NextAttemptAt: time.Now().UTC(),
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if _, err := uc.repo.Add(ctx, o); err != nil {
+		uc.logger.Debug("error func AddNotification, method Add by path"+
+			" internal/usecases/outbox/outbox.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Dispatch delivers queued notifications until the context is done
+func (uc *OutboxUseCases) Dispatch(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// drain the queue before waiting for the next tick
+			for {
+				count, err := uc.dispatchBatch(ctx)
+				if err != nil {
+					uc.logger.Debug("error func Dispatch, method dispatchBatch by path"+
+						" internal/usecases/outbox/outbox.go", zap.Error(err))
+					break
+				}
+				if count < outboxBatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// dispatchBatch claims the due notifications and sends them one by one. Nothing is locked while the notifier
+// waits for telegram, the claimed rows are marked sent or failed right after their own attempt.
+func (uc *OutboxUseCases) dispatchBatch(ctx context.Context) (int, error) {
+	list, err := uc.repo.ClaimPending(ctx, outboxBatchSize, time.Now().UTC().Add(outboxLease))
+	if err != nil {
+		return 0, err
+	}
+	for _, o := range list {
+		// the rest of the batch is sent by the next dispatcher when the lease expires
+		if ctx.Err() != nil {
+			break
+		}
+		if err := uc.send(ctx, o); err != nil {
+			return len(list), err
+		}
+	}
+	return len(list), nil
+}
+
+func (uc *OutboxUseCases) send(ctx context.Context, o *entity.NotificationOutbox) error {
+	sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+	defer cancel()
+	if err := uc.notifier.Notify(sendCtx, o.Payload); err != nil {
+		o.Attempts++
+		o.LastError = err.Error()
+		o.NextAttemptAt = time.Now().UTC().Add(outboxBackoff(o.Attempts))
+		o.UpdatedAt = time.Now().UTC()
+		if o.Attempts >= outboxMaxAttempts {
+			o.Status = entity.OutboxStatusDead
+		}
+		return uc.repo.MarkFailed(ctx, o)
+	}
+	return uc.repo.MarkSent(ctx, o.ID, time.Now().UTC())
+}
+
+// outboxBackoff doubles the delay after every failed attempt
+func outboxBackoff(attempts uint32) time.Duration {
+	d := outboxBaseBackoff
+	for i := uint32(1); i < attempts; i++ {
+		d *= 2
+		if d >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return d
+}
+
+func (uc *OutboxUseCases) SelectList(
+	ctx context.Context, qp *entity.QueryParamsOutboxList) (*entity.ResponseListOutbox, error) {
+	response, err := uc.repo.SelectList(ctx, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectList, method SelectList by path"+
+			" internal/usecases/outbox/outbox.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *OutboxUseCases) FindByID(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error) {
+	response, isExist, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		uc.logger.Debug("error func FindByID, method FindByID by path"+
+			" internal/usecases/outbox/outbox.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
+
+func (uc *OutboxUseCases) Replay(ctx context.Context, id uint64) (*entity.NotificationOutbox, bool, error) {
+	response, isExist, err := uc.repo.Replay(ctx, id)
+	if err != nil {
+		uc.logger.Debug("error func Replay, method Replay by path"+
+			" internal/usecases/outbox/outbox.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// fakeOutboxRepo hands out the pending notifications once and records how they were marked
+type fakeOutboxRepo struct {
+	OutboxRepo
+	pending    []*entity.NotificationOutbox
+	leaseUntil time.Time
+	added      []*entity.NotificationOutbox
+	sent       []uint64
+	failed     []*entity.NotificationOutbox
+}
+
+func (r *fakeOutboxRepo) Add(_ context.Context, o *entity.NotificationOutbox) (*entity.NotificationOutbox, error) {
+	r.added = append(r.added, o)
+	return o, nil
+}
+
+func (r *fakeOutboxRepo) ClaimPending(
+	_ context.Context, limit uint64, leaseUntil time.Time) ([]*entity.NotificationOutbox, error) {
+	r.leaseUntil = leaseUntil
+	n := min(uint64(len(r.pending)), limit)
+	list := r.pending[:n]
+	r.pending = r.pending[n:]
+	return list, nil
+}
+
+func (r *fakeOutboxRepo) MarkSent(_ context.Context, id uint64, _ time.Time) error {
+	r.sent = append(r.sent, id)
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkFailed(_ context.Context, o *entity.NotificationOutbox) error {
+	r.failed = append(r.failed, o)
+	return nil
+}
+
+// fakeNotifier fails the chats in errs, the chats in hang don't answer until the context is done
+type fakeNotifier struct {
+	errs     map[uint64]error
+	hang     map[uint64]bool
+	deadline bool
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, c *entity.Content) error {
+	_, n.deadline = ctx.Deadline()
+	if n.hang[c.ChatID] {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return n.errs[c.ChatID]
+}
+
+func newOutboxNotification(id uint64, attempts uint32) *entity.NotificationOutbox {
+	return &entity.NotificationOutbox{
+		ID:       id,
+		Payload:  &entity.Content{ChatID: id},
+		Status:   entity.OutboxStatusPending,
+		Attempts: attempts,
+	}
+}
+
+func TestOutboxUseCases_DispatchBatch(t *testing.T) {
+	r := &fakeOutboxRepo{pending: []*entity.NotificationOutbox{
+		newOutboxNotification(1, 0),
+		newOutboxNotification(2, 0),
+		newOutboxNotification(3, outboxMaxAttempts-1),
+	}}
+	n := &fakeNotifier{errs: map[uint64]error{
+		2: errors.New("bad gateway"),
+		3: errors.New("bad gateway"),
+	}}
+	uc := NewOutboxUseCases(zap.NewNop(), r, n)
+	start := time.Now().UTC()
+	count, err := uc.dispatchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("dispatchBatch() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("dispatchBatch() = %d, want 3", count)
+	}
+	if r.leaseUntil.Before(start.Add(outboxLease)) {
+		t.Errorf("lease until %v, want at least %v", r.leaseUntil, start.Add(outboxLease))
+	}
+	if !n.deadline {
+		t.Error("notifier was called without a deadline")
+	}
+	if len(r.sent) != 1 || r.sent[0] != 1 {
+		t.Errorf("sent %v, want [1]", r.sent)
+	}
+	if len(r.failed) != 2 {
+		t.Fatalf("failed %d notifications, want 2", len(r.failed))
+	}
+	retried, dead := r.failed[0], r.failed[1]
+	if retried.Status != entity.OutboxStatusPending || retried.Attempts != 1 || retried.LastError != "bad gateway" {
+		t.Errorf("retried notification = %+v", retried)
+	}
+	if !retried.NextAttemptAt.After(start) {
+		t.Errorf("next attempt at %v, want after %v", retried.NextAttemptAt, start)
+	}
+	if dead.Status != entity.OutboxStatusDead || dead.Attempts != outboxMaxAttempts {
+		t.Errorf("dead notification = %+v", dead)
+	}
+}
+
+func TestOutboxUseCases_DispatchBatch_Canceled(t *testing.T) {
+	r := &fakeOutboxRepo{pending: []*entity.NotificationOutbox{
+		newOutboxNotification(1, 0),
+		newOutboxNotification(2, 0),
+	}}
+	n := &fakeNotifier{hang: map[uint64]bool{1: true}}
+	uc := NewOutboxUseCases(zap.NewNop(), r, n)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := uc.dispatchBatch(ctx); err != nil {
+		t.Fatalf("dispatchBatch() error = %v", err)
+	}
+	// the hanging send failed, the rest of the batch waits for the lease to expire
+	if len(r.failed) != 1 || r.failed[0].ID != 1 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("failed %v, want the notification 1", r.failed)
+	}
+	if len(r.sent) != 0 {
+		t.Errorf("sent %v, want none", r.sent)
+	}
+}
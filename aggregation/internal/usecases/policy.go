@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"go.uber.org/zap"
+)
+
+// ErrForbidden - the principal isn't allowed to change the resource
+var ErrForbidden = errors.New("resource belongs to another profile")
+
+// authorize lets the owner of the resource and administrators through
+func (uc *ProfileUseCases) authorize(pr *entity.Principal, ownerID uint64) error {
+	if pr == nil {
+		return ErrForbidden
+	}
+	if pr.IsAdmin || (pr.ProfileID != 0 && pr.ProfileID == ownerID) {
+		return nil
+	}
+	uc.logger.Debug("access denied", zap.Uint64("profileId", pr.ProfileID), zap.Uint64("ownerId", ownerID))
+	return ErrForbidden
+}
+
+func (uc *ProfileUseCases) AuthorizeProfile(pr *entity.Principal, p *entity.Profile) error {
+	return uc.authorize(pr, p.ID)
+}
+
+func (uc *ProfileUseCases) AuthorizeImage(pr *entity.Principal, i *entity.ImageProfile) error {
+	return uc.authorize(pr, i.ProfileID)
+}
+
+func (uc *ProfileUseCases) AuthorizeReview(pr *entity.Principal, r *entity.ResponseReviewProfile) error {
+	return uc.authorize(pr, r.ProfileID)
+}
+
+// AuthorizeLike - a like belongs to the profile that liked
+func (uc *ProfileUseCases) AuthorizeLike(pr *entity.Principal, l *entity.LikeProfile) error {
+	return uc.authorize(pr, l.ProfileID)
+}
+
+// AuthorizeBlock - a block belongs to the profile that blocked
+func (uc *ProfileUseCases) AuthorizeBlock(pr *entity.Principal, b *entity.BlockedProfile) error {
+	return uc.authorize(pr, b.ProfileID)
+}
+
+// AuthorizeComplaint - a complaint belongs to the profile that complained
+func (uc *ProfileUseCases) AuthorizeComplaint(pr *entity.Principal, c *entity.ComplaintProfile) error {
+	return uc.authorize(pr, c.ProfileID)
+}
@@ -2,9 +2,18 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
 	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+var (
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to another profile")
+	ErrProfileAlreadyDeleted = errors.New("profile has already been deleted")
+	ErrProfileErased         = errors.New("profile data has been erased on deletion")
 )
 
 type ProfileRepo interface {
@@ -13,9 +22,16 @@ type ProfileRepo interface {
 	UpdateLastOnline(ctx context.Context, profileID uint64) error
 	Delete(ctx context.Context, p *entity.Profile) (*entity.Profile, error)
 	SelectList(ctx context.Context, qp *entity.QueryParamsProfileList) (*entity.ResponseListProfile, error)
+	SelectDeck(ctx context.Context, p *entity.Profile, qp *entity.QueryParamsProfileList,
+		passedAfter time.Time) (*entity.ResponseDeck, error)
+	AddPass(ctx context.Context, p *entity.PassProfile) (*entity.PassProfile, error)
 	FindById(ctx context.Context, id uint64) (*entity.Profile, error)
 	FindBySessionID(ctx context.Context, sessionID string) (*entity.Profile, error)
 	FindByTelegramId(ctx context.Context, telegramID uint64) (*entity.Profile, error)
+	FindByKeycloakUserID(ctx context.Context, keycloakUserID string) (*entity.Profile, error)
+	LinkTelegram(ctx context.Context, profileID uint64, telegramID uint64) error
+	LinkKeycloakUser(ctx context.Context, profileID uint64, keycloakUserID string) (bool, error)
+	UnlinkIdentity(ctx context.Context, profileID uint64) error
 	AddTelegram(ctx context.Context, t *entity.TelegramProfile) (*entity.TelegramProfile, error)
 	UpdateTelegram(ctx context.Context, t *entity.TelegramProfile) (*entity.TelegramProfile, error)
 	DeleteTelegram(ctx context.Context, t *entity.TelegramProfile) (*entity.TelegramProfile, error)
@@ -34,6 +50,8 @@ type ProfileRepo interface {
 	AddImage(ctx context.Context, p *entity.ImageProfile) (*entity.ImageProfile, error)
 	UpdateImage(ctx context.Context, p *entity.ImageProfile) (*entity.ImageProfile, error)
 	FindImageById(ctx context.Context, imageID uint64) (*entity.ImageProfile, error)
+	SetPrimaryImage(ctx context.Context, profileID uint64, imageID uint64) error
+	EnsurePrimaryImage(ctx context.Context, profileID uint64) error
 	SelectListPublicImage(ctx context.Context, profileID uint64) ([]*entity.ImageProfile, error)
 	SelectListImage(ctx context.Context, profileID uint64) ([]*entity.ImageProfile, error)
 	CheckIfCommonImageExists(ctx context.Context, profileID uint64, fileName string) (bool, uint64, error)
@@ -47,6 +65,17 @@ type ProfileRepo interface {
 	DeleteLike(ctx context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error)
 	FindLikeByLikedUserID(ctx context.Context, profileID uint64, humanID uint64) (*entity.LikeProfile, bool, error)
 	FindLikeByID(ctx context.Context, id uint64) (*entity.LikeProfile, bool, error)
+	SelectListIncomingLike(
+		ctx context.Context, profileID uint64, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error)
+	SelectListOutgoingLike(
+		ctx context.Context, profileID uint64, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error)
+	AddMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, bool, error)
+	FindMatchByProfiles(ctx context.Context, profileID uint64, matchedUserID uint64) (*entity.MatchProfile, bool, error)
+	FindMatchByID(ctx context.Context, id uint64) (*entity.MatchProfile, bool, error)
+	DeleteMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, error)
+	SelectMatchList(
+		ctx context.Context, profileID uint64, qp *entity.QueryParamsMatchList) (*entity.ResponseListMatch, error)
+	SelectListMatchedUserID(ctx context.Context, profileID uint64) ([]uint64, error)
 	AddBlock(ctx context.Context, p *entity.BlockedProfile) (*entity.BlockedProfile, error)
 	UpdateBlock(ctx context.Context, p *entity.BlockedProfile) (*entity.BlockedProfile, error)
 	FindBlockByID(ctx context.Context, id uint64) (*entity.BlockedProfile, bool, error)
@@ -54,22 +83,40 @@ type ProfileRepo interface {
 	UpdateComplaint(ctx context.Context, p *entity.ComplaintProfile) (*entity.ComplaintProfile, error)
 	FindComplaintByID(ctx context.Context, id uint64) (*entity.ComplaintProfile, bool, error)
 	SelectListComplaintByID(ctx context.Context, complaintUserID uint64) ([]*entity.ComplaintProfile, error)
+	SelectListByAdmin(
+		ctx context.Context, qp *entity.QueryParamsAdminProfileList) (*entity.ResponseListAdminProfile, error)
+	UpdateIsBlocked(ctx context.Context, profileID uint64, isBlocked bool) (bool, error)
+	UpdateIsPremium(ctx context.Context, profileID uint64, isPremium bool) (bool, error)
+	Restore(ctx context.Context, profileID uint64) (bool, error)
 }
 
 type ProfileUseCases struct {
-	logger logger.Logger
-	repo   ProfileRepo
-	Hub    *entity.Hub
+	logger       logger.Logger
+	repo         ProfileRepo
+	tx           Transactor
+	images       ImageStorage
+	passCooldown time.Duration
+	Outbox       *OutboxUseCases
+	Hub          *entity.Hub
 }
 
-func NewProfileUseCases(l logger.Logger, pr ProfileRepo, h *entity.Hub) *ProfileUseCases {
+func NewProfileUseCases(l logger.Logger, pr ProfileRepo, tx Transactor, is ImageStorage, passCooldown time.Duration,
+	o *OutboxUseCases, h *entity.Hub) *ProfileUseCases {
 	return &ProfileUseCases{
-		logger: l,
-		repo:   pr,
-		Hub:    h,
+		logger:       l,
+		repo:         pr,
+		tx:           tx,
+		images:       is,
+		passCooldown: passCooldown,
+		Outbox:       o,
+		Hub:          h,
 	}
 }
 
+func (uc *ProfileUseCases) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return uc.tx.WithinTransaction(ctx, fn)
+}
+
 func (uc *ProfileUseCases) Add(ctx context.Context, p *entity.Profile) (*entity.Profile, error) {
 	response, err := uc.repo.Add(ctx, p)
 	if err != nil {
@@ -178,6 +225,126 @@ func (uc *ProfileUseCases) DeleteImage(ctx context.Context, i *entity.ImageProfi
 	return response, nil
 }
 
+func (uc *ProfileUseCases) FindByKeycloakUserID(ctx context.Context, keycloakUserID string) (*entity.Profile, error) {
+	response, err := uc.repo.FindByKeycloakUserID(ctx, keycloakUserID)
+	if err != nil {
+		uc.logger.Debug("error func FindByKeycloakUserID, method FindByKeycloakUserID by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// LinkKeycloakUser binds the keycloak user to the profile, both sides can have only one link
+func (uc *ProfileUseCases) LinkKeycloakUser(ctx context.Context, profileID uint64, keycloakUserID string) error {
+	linked, err := uc.repo.FindByKeycloakUserID(ctx, keycloakUserID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		uc.logger.Debug("error func LinkKeycloakUser, method FindByKeycloakUserID by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return err
+	}
+	if linked != nil && linked.ID != profileID {
+		return ErrIdentityAlreadyLinked
+	}
+	ok, err := uc.repo.LinkKeycloakUser(ctx, profileID, keycloakUserID)
+	if err != nil {
+		uc.logger.Debug("error func LinkKeycloakUser, method LinkKeycloakUser by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return err
+	}
+	if !ok {
+		return ErrIdentityAlreadyLinked
+	}
+	return nil
+}
+
+// SoftDelete erases the personal data of the profile, marks it as deleted and frees its identities in one
+// transaction. The image files are removed only after the commit.
+func (uc *ProfileUseCases) SoftDelete(ctx context.Context, p *entity.Profile) (*entity.Profile, error) {
+	if p.IsDeleted {
+		return nil, ErrProfileAlreadyDeleted
+	}
+	var filePaths []string
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		imageList, err := uc.repo.SelectListImage(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		for _, i := range imageList {
+			imageDTO := &entity.ImageProfile{
+				ID:        i.ID,
+				ProfileID: i.ProfileID,
+				CreatedAt: i.CreatedAt,
+				UpdatedAt: time.Now().UTC(),
+				IsDeleted: true,
+				IsBlocked: i.IsBlocked,
+				IsPrimary: i.IsPrimary,
+				IsPrivate: i.IsPrivate,
+			}
+			if _, err := uc.repo.DeleteImage(ctx, imageDTO); err != nil {
+				return err
+			}
+			filePaths = append(filePaths, imageFiles(i)...)
+		}
+		t, err := uc.repo.FindTelegramByProfileID(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := uc.repo.DeleteTelegram(ctx, &entity.TelegramProfile{ID: t.ID, ProfileID: p.ID}); err != nil {
+			return err
+		}
+		n, err := uc.repo.FindNavigatorByProfileID(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		navigatorDto := &entity.NavigatorProfile{
+			ID:        n.ID,
+			ProfileID: p.ID,
+			Location:  &entity.Point{Latitude: 0.0, Longitude: 0.0},
+		}
+		if _, err := uc.repo.DeleteNavigator(ctx, navigatorDto); err != nil {
+			return err
+		}
+		f, err := uc.repo.FindFilterByProfileID(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := uc.repo.DeleteFilter(ctx, &entity.FilterProfile{ID: f.ID, ProfileID: p.ID}); err != nil {
+			return err
+		}
+		profileDto := &entity.Profile{
+			ID:         p.ID,
+			Birthday:   p.Birthday,
+			IsDeleted:  true,
+			CreatedAt:  p.CreatedAt,
+			UpdatedAt:  time.Now().UTC(),
+			LastOnline: time.Now().UTC(),
+		}
+		if _, err := uc.repo.Delete(ctx, profileDto); err != nil {
+			return err
+		}
+		return uc.repo.UnlinkIdentity(ctx, p.ID)
+	})
+	if err != nil {
+		uc.logger.Debug("error func SoftDelete, method WithinTransaction by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	uc.removeImages(filePaths)
+	response, err := uc.repo.FindById(ctx, p.ID)
+	if err != nil {
+		uc.logger.Debug("error func SoftDelete, method FindById by path internal/usecases/profile/profile.go",
+			zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// isErased reports whether SoftDelete has blanked the session and the name of the profile
+func isErased(p *entity.Profile) bool {
+	return p.SessionID == "" && p.DisplayName == ""
+}
+
 func (uc *ProfileUseCases) FindImageById(ctx context.Context, imageID uint64) (*entity.ImageProfile, error) {
 	response, err := uc.repo.FindImageById(ctx, imageID)
 	if err != nil {
@@ -457,6 +624,115 @@ func (uc *ProfileUseCases) FindLikeByID(ctx context.Context, id uint64) (*entity
 	return response, isExist, nil
 }
 
+// SelectListIncomingLike returns profiles that liked the viewer. Unless the viewer has a premium account
+// the cards are blurred by blurLike.
+func (uc *ProfileUseCases) SelectListIncomingLike(
+	ctx context.Context, p *entity.Profile, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error) {
+	response, err := uc.repo.SelectListIncomingLike(ctx, p.ID, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectListIncomingLike, method SelectListIncomingLike by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	if !p.IsPremium {
+		for _, l := range response.Content {
+			blurLike(l)
+		}
+	}
+	return response, nil
+}
+
+// blurDistanceStep - the blurred distance is rounded up to this number of meters
+const blurDistanceStep = 5000
+
+// blurLike hides the liker's id, like id and photo. The last online time is cut to the day and the distance is
+// rounded up, so they can't be matched with the same profile in the search list.
+func blurLike(l *entity.ContentListLike) {
+	l.ID = 0
+	l.LikeID = 0
+	l.Image = nil
+	l.IsBlurred = true
+	l.LastOnline = l.LastOnline.UTC().Truncate(24 * time.Hour)
+	if l.Navigator != nil {
+		l.Navigator = &entity.ResponseNavigatorProfile{
+			Distance: math.Max(1, math.Ceil(l.Navigator.Distance/blurDistanceStep)) * blurDistanceStep,
+		}
+	}
+}
+
+func (uc *ProfileUseCases) SelectListOutgoingLike(
+	ctx context.Context, profileID uint64, qp *entity.QueryParamsLikeList) (*entity.ResponseListLike, error) {
+	response, err := uc.repo.SelectListOutgoingLike(ctx, profileID, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectListOutgoingLike, method SelectListOutgoingLike by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ProfileUseCases) AddMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, bool, error) {
+	response, isCreated, err := uc.repo.AddMatch(ctx, p)
+	if err != nil {
+		uc.logger.Debug("error func AddMatch, method AddMatch by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, isCreated, err
+	}
+	return response, isCreated, nil
+}
+
+func (uc *ProfileUseCases) FindMatchByProfiles(
+	ctx context.Context, profileID uint64, matchedUserID uint64) (*entity.MatchProfile, bool, error) {
+	response, isExist, err := uc.repo.FindMatchByProfiles(ctx, profileID, matchedUserID)
+	if err != nil {
+		uc.logger.Debug("error func FindMatchByProfiles, method FindMatchByProfiles by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
+
+func (uc *ProfileUseCases) FindMatchByID(ctx context.Context, id uint64) (*entity.MatchProfile, bool, error) {
+	response, isExist, err := uc.repo.FindMatchByID(ctx, id)
+	if err != nil {
+		uc.logger.Debug("error func FindMatchByID, method FindMatchByID by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, isExist, err
+	}
+	return response, isExist, nil
+}
+
+func (uc *ProfileUseCases) DeleteMatch(ctx context.Context, p *entity.MatchProfile) (*entity.MatchProfile, error) {
+	response, err := uc.repo.DeleteMatch(ctx, p)
+	if err != nil {
+		uc.logger.Debug("error func DeleteMatch, method DeleteMatch by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ProfileUseCases) SelectMatchList(
+	ctx context.Context, profileID uint64, qp *entity.QueryParamsMatchList) (*entity.ResponseListMatch, error) {
+	response, err := uc.repo.SelectMatchList(ctx, profileID, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectMatchList, method SelectMatchList by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ProfileUseCases) SelectListMatchedUserID(ctx context.Context, profileID uint64) ([]uint64, error) {
+	response, err := uc.repo.SelectListMatchedUserID(ctx, profileID)
+	if err != nil {
+		uc.logger.Debug("error func SelectListMatchedUserID, method SelectListMatchedUserID by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
 func (uc *ProfileUseCases) AddBlock(
 	ctx context.Context, p *entity.BlockedProfile) (*entity.BlockedProfile, error) {
 	response, err := uc.repo.AddBlock(ctx, p)
@@ -531,3 +807,61 @@ func (uc *ProfileUseCases) SelectListComplaintByID(
 	}
 	return response, nil
 }
+
+func (uc *ProfileUseCases) SelectListByAdmin(
+	ctx context.Context, qp *entity.QueryParamsAdminProfileList) (*entity.ResponseListAdminProfile, error) {
+	response, err := uc.repo.SelectListByAdmin(ctx, qp)
+	if err != nil {
+		uc.logger.Debug("error func SelectListByAdmin, method SelectListByAdmin by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (uc *ProfileUseCases) UpdateIsBlocked(ctx context.Context, profileID uint64, isBlocked bool) (bool, error) {
+	isExist, err := uc.repo.UpdateIsBlocked(ctx, profileID, isBlocked)
+	if err != nil {
+		uc.logger.Debug("error func UpdateIsBlocked, method UpdateIsBlocked by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	return isExist, nil
+}
+
+func (uc *ProfileUseCases) UpdateIsPremium(ctx context.Context, profileID uint64, isPremium bool) (bool, error) {
+	isExist, err := uc.repo.UpdateIsPremium(ctx, profileID, isPremium)
+	if err != nil {
+		uc.logger.Debug("error func UpdateIsPremium, method UpdateIsPremium by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	return isExist, nil
+}
+
+// Restore clears the deleted flag of the profile, reports false if there is no such deleted profile.
+// SoftDelete erases the data and the owner of the profile, such a profile can't be restored.
+func (uc *ProfileUseCases) Restore(ctx context.Context, profileID uint64) (bool, error) {
+	p, err := uc.repo.FindById(ctx, profileID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		uc.logger.Debug("error func Restore, method FindById by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	if !p.IsDeleted {
+		return false, nil
+	}
+	if isErased(p) {
+		return false, ErrProfileErased
+	}
+	isExist, err := uc.repo.Restore(ctx, profileID)
+	if err != nil {
+		uc.logger.Debug("error func Restore, method Restore by path"+
+			" internal/usecases/profile/profile.go", zap.Error(err))
+		return false, err
+	}
+	return isExist, nil
+}
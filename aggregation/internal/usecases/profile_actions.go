@@ -0,0 +1,1124 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrProfileNotFound      = errors.New("profile not found")
+	ErrProfileAlreadyExists = errors.New("profile already exists")
+	ErrProfileBlocked       = errors.New("profile has been blocked")
+	ErrProfileUnavailable   = errors.New("profile has been deleted or blocked")
+	ErrImageAlreadyDeleted  = errors.New("image has already been deleted")
+	ErrBlockNotFound        = errors.New("block not found")
+	ErrLikeNotFound         = errors.New("like not found")
+	ErrMatchNotFound        = errors.New("match not found")
+	ErrSelfAction           = errors.New("profile cannot like, pass, block or report itself")
+)
+
+// complaintsToBlock - the number of complaints during a month after which the profile is blocked
+const complaintsToBlock = 2
+
+const defaultLikeMessage = "Ты понравился"
+
+// lookingForAll - the profile is open to any kind of relationship
+const lookingForAll = "all"
+
+// defaultDeckSize - the number of profiles in the deck when the client doesn't ask for a size
+const defaultDeckSize = 10
+
+// ImageStorage keeps the uploaded image files of the profiles
+type ImageStorage interface {
+	Save(owner string, file *multipart.FileHeader) (*entity.ImageProfile, error)
+	Remove(url string) error
+}
+
+// ProfileInput - the profile data decoded from a create or edit request. Nil telegram or navigator are left
+// as they are on edit, the images are the uploaded files.
+type ProfileInput struct {
+	Profile   *entity.Profile
+	Telegram  *entity.TelegramProfile
+	Filter    *entity.FilterProfile
+	Navigator *entity.NavigatorProfile
+	Images    []*multipart.FileHeader
+}
+
+// CreateProfile registers the profile of the telegram user with its images, filter and location.
+// A telegram user can have only one profile.
+func (uc *ProfileUseCases) CreateProfile(ctx context.Context, in *ProfileInput) (*entity.Profile, error) {
+	if _, err := uc.repo.FindByTelegramId(ctx, in.Telegram.TelegramID); err == nil {
+		return nil, ErrProfileAlreadyExists
+	} else if !errors.Is(err, ErrNotFound) {
+		uc.logger.Debug("error func CreateProfile, method FindByTelegramId by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	images, filePaths, err := uc.saveImages(strconv.FormatUint(in.Telegram.TelegramID, 10), in.Images)
+	if err != nil {
+		return nil, err
+	}
+	p := in.Profile
+	p.IsDeleted = false
+	p.IsBlocked = false
+	p.IsPremium = false
+	p.IsShowDistance = true
+	p.IsInvisible = false
+	if p.LookingFor == "" {
+		p.LookingFor = lookingForAll
+	}
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = time.Now().UTC()
+	p.LastOnline = time.Now().UTC()
+	p.Images = images
+	// the private chat with the bot has the same id as the user
+	in.Telegram.ChatID = in.Telegram.TelegramID
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		newProfile, err := uc.repo.Add(ctx, p)
+		if err != nil {
+			return err
+		}
+		for _, i := range p.Images {
+			i.ProfileID = newProfile.ID
+			if _, err := uc.repo.AddImage(ctx, i); err != nil {
+				return err
+			}
+		}
+		if err := uc.repo.EnsurePrimaryImage(ctx, newProfile.ID); err != nil {
+			return err
+		}
+		in.Telegram.ProfileID = newProfile.ID
+		if _, err := uc.repo.AddTelegram(ctx, in.Telegram); err != nil {
+			return err
+		}
+		if err := uc.repo.LinkTelegram(ctx, newProfile.ID, in.Telegram.TelegramID); err != nil {
+			return err
+		}
+		in.Filter.ProfileID = newProfile.ID
+		if _, err := uc.repo.AddFilter(ctx, in.Filter); err != nil {
+			return err
+		}
+		in.Navigator.ProfileID = newProfile.ID
+		if _, err := uc.repo.AddNavigator(ctx, in.Navigator); err != nil {
+			return err
+		}
+		p.ID = newProfile.ID
+		return nil
+	})
+	if err != nil {
+		uc.logger.Debug("error func CreateProfile, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		uc.removeImages(filePaths)
+		// a concurrent request has linked the same telegram account first
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrProfileAlreadyExists
+		}
+		return nil, err
+	}
+	return uc.loadProfile(ctx, p.ID)
+}
+
+// EditProfile saves the changes of the profile with the given id, the principal's own profile when the id is 0.
+// New images replace the ones with the same name.
+func (uc *ProfileUseCases) EditProfile(
+	ctx context.Context, pr *entity.Principal, profileID uint64, in *ProfileInput) (*entity.Profile, error) {
+	profileInDB, err := uc.findProfileForChange(ctx, pr, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profileInDB.IsDeleted {
+		return nil, ErrProfileAlreadyDeleted
+	}
+	if profileInDB.IsBlocked {
+		return nil, ErrProfileBlocked
+	}
+	var filePaths []string
+	if len(in.Images) > 0 {
+		t, err := uc.repo.FindTelegramByProfileID(ctx, profileInDB.ID)
+		if err != nil {
+			uc.logger.Debug("error func EditProfile, method FindTelegramByProfileID by path"+
+				" internal/usecases/profile/profile_actions.go", zap.Error(err))
+			return nil, err
+		}
+		profileInDB.Images, filePaths, err = uc.saveImages(strconv.FormatUint(t.TelegramID, 10), in.Images)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := profileInDB
+	p.DisplayName = in.Profile.DisplayName
+	p.Birthday = in.Profile.Birthday
+	p.Gender = in.Profile.Gender
+	p.Location = in.Profile.Location
+	p.Description = in.Profile.Description
+	p.Height = in.Profile.Height
+	p.Weight = in.Profile.Weight
+	if in.Profile.LookingFor != "" {
+		p.LookingFor = in.Profile.LookingFor
+	}
+	p.UpdatedAt = time.Now().UTC()
+	p.LastOnline = time.Now().UTC()
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := uc.repo.Update(ctx, p); err != nil {
+			return err
+		}
+		for _, i := range p.Images {
+			exists, imageID, err := uc.repo.CheckIfCommonImageExists(ctx, p.ID, i.Name)
+			if err != nil {
+				return err
+			}
+			i.ProfileID = p.ID
+			if exists {
+				i.ID = imageID
+				_, err = uc.repo.UpdateImage(ctx, i)
+			} else {
+				_, err = uc.repo.AddImage(ctx, i)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := uc.repo.EnsurePrimaryImage(ctx, p.ID); err != nil {
+			return err
+		}
+		// the telegram data is refreshed only when the owner edits the profile from the mini app
+		if in.Telegram != nil && !pr.IsAdmin {
+			telegramInDB, err := uc.repo.FindTelegramByProfileID(ctx, p.ID)
+			if err != nil {
+				return err
+			}
+			telegramInDB.UserName = in.Telegram.UserName
+			telegramInDB.Firstname = in.Telegram.Firstname
+			telegramInDB.Lastname = in.Telegram.Lastname
+			telegramInDB.LanguageCode = in.Telegram.LanguageCode
+			telegramInDB.AllowsWriteToPm = in.Telegram.AllowsWriteToPm
+			telegramInDB.QueryID = in.Telegram.QueryID
+			if _, err := uc.repo.UpdateTelegram(ctx, telegramInDB); err != nil {
+				return err
+			}
+		}
+		filterInDB, err := uc.repo.FindFilterByProfileID(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		filterInDB.SearchGender = in.Filter.SearchGender
+		filterInDB.LookingFor = in.Filter.LookingFor
+		if _, err := uc.repo.UpdateFilter(ctx, filterInDB); err != nil {
+			return err
+		}
+		if in.Navigator != nil {
+			in.Navigator.ProfileID = p.ID
+			if _, err := uc.repo.UpdateNavigator(ctx, in.Navigator); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		uc.logger.Debug("error func EditProfile, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		uc.removeImages(filePaths)
+		return nil, err
+	}
+	return uc.loadProfile(ctx, p.ID)
+}
+
+// DeleteProfile soft deletes the profile with the given id, the principal's own profile when the id is 0
+func (uc *ProfileUseCases) DeleteProfile(
+	ctx context.Context, pr *entity.Principal, profileID uint64) (*entity.Profile, error) {
+	p, err := uc.findProfileForChange(ctx, pr, profileID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.SoftDelete(ctx, p)
+}
+
+// DeleteProfileImage marks the image as deleted and removes its files, another image becomes primary
+// when the deleted one was primary
+func (uc *ProfileUseCases) DeleteProfileImage(
+	ctx context.Context, pr *entity.Principal, imageID uint64) (*entity.ImageProfile, error) {
+	imageInDB, err := uc.repo.FindImageById(ctx, imageID)
+	if err != nil {
+		uc.logger.Debug("error func DeleteProfileImage, method FindImageById by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if imageInDB.IsDeleted {
+		return nil, ErrImageAlreadyDeleted
+	}
+	if err := uc.AuthorizeImage(pr, imageInDB); err != nil {
+		return nil, err
+	}
+	imageDTO := &entity.ImageProfile{
+		ID:        imageInDB.ID,
+		ProfileID: imageInDB.ProfileID,
+		Name:      "",
+		Url:       "",
+		Size:      0,
+		CreatedAt: imageInDB.CreatedAt,
+		UpdatedAt: time.Now().UTC(),
+		IsDeleted: true,
+		IsBlocked: imageInDB.IsBlocked,
+		IsPrimary: imageInDB.IsPrimary,
+		IsPrivate: imageInDB.IsPrivate,
+	}
+	var response *entity.ImageProfile
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		response, err = uc.repo.DeleteImage(ctx, imageDTO)
+		if err != nil {
+			return err
+		}
+		return uc.repo.EnsurePrimaryImage(ctx, imageInDB.ProfileID)
+	})
+	if err != nil {
+		uc.logger.Debug("error func DeleteProfileImage, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	uc.removeImages(imageFiles(imageInDB))
+	return response, nil
+}
+
+// SetPrimaryProfileImage makes the image the one shown in the feed and the lists
+func (uc *ProfileUseCases) SetPrimaryProfileImage(
+	ctx context.Context, pr *entity.Principal, imageID uint64) (*entity.ImageProfile, error) {
+	imageInDB, err := uc.repo.FindImageById(ctx, imageID)
+	if err != nil {
+		uc.logger.Debug("error func SetPrimaryProfileImage, method FindImageById by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if err := uc.AuthorizeImage(pr, imageInDB); err != nil {
+		return nil, err
+	}
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return uc.repo.SetPrimaryImage(ctx, imageInDB.ProfileID, imageInDB.ID)
+	})
+	if err != nil {
+		uc.logger.Debug("error func SetPrimaryProfileImage, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	imageInDB.IsPrimary = true
+	return imageInDB, nil
+}
+
+// refreshViewer marks the viewer online and moves them to the point the client sent, if it sent one
+func (uc *ProfileUseCases) refreshViewer(
+	ctx context.Context, viewer *entity.Profile, latitude, longitude *float64) error {
+	if err := uc.repo.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		uc.logger.Debug("error func refreshViewer, method UpdateLastOnline by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return err
+	}
+	if latitude == nil || longitude == nil {
+		return nil
+	}
+	navigatorDto := &entity.NavigatorProfile{
+		ProfileID: viewer.ID,
+		Location: &entity.Point{
+			Latitude:  *latitude,
+			Longitude: *longitude,
+		},
+	}
+	if _, err := uc.repo.UpdateNavigator(ctx, navigatorDto); err != nil {
+		uc.logger.Debug("error func refreshViewer, method UpdateNavigator by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SearchProfiles returns the page of the search list. The query of the search becomes the saved filter of the
+// viewer, so the next visit starts with the same criteria.
+func (uc *ProfileUseCases) SearchProfiles(ctx context.Context, viewer *entity.Profile,
+	qp *entity.QueryParamsProfileList) (*entity.ResponseListProfile, error) {
+	latitude, longitude := qp.Latitude, qp.Longitude
+	if qp.Cursor != "" {
+		// the cursor holds the distance from the point of the first page, the viewer stays there until the list
+		// is requested from the first page again, otherwise the next pages skip or repeat profiles
+		latitude, longitude = nil, nil
+	}
+	if err := uc.refreshViewer(ctx, viewer, latitude, longitude); err != nil {
+		return nil, err
+	}
+	f, err := uc.repo.FindFilterByProfileID(ctx, viewer.ID)
+	if err != nil {
+		uc.logger.Debug("error func SearchProfiles, method FindFilterByProfileID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	filterDto := &entity.FilterProfile{
+		ID:           f.ID,
+		ProfileID:    viewer.ID,
+		SearchGender: qp.SearchGender,
+		LookingFor:   qp.LookingFor,
+		AgeFrom:      qp.AgeFrom,
+		AgeTo:        qp.AgeTo,
+		Distance:     qp.Distance,
+		Page:         qp.Page,
+		Size:         qp.Size,
+		HeightFrom:   qp.HeightFrom,
+		HeightTo:     qp.HeightTo,
+		WeightFrom:   qp.WeightFrom,
+		WeightTo:     qp.WeightTo,
+	}
+	if _, err := uc.repo.UpdateFilter(ctx, filterDto); err != nil {
+		uc.logger.Debug("error func SearchProfiles, method UpdateFilter by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	qp.SessionID = viewer.SessionID
+	response, err := uc.repo.SelectList(ctx, qp)
+	if err != nil {
+		uc.logger.Debug("error func SearchProfiles, method SelectList by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetProfileDetail returns the profile as the viewer sees it, with the distance between them and the viewer's like.
+// A profile whose match with the viewer was deleted is not found.
+func (uc *ProfileUseCases) GetProfileDetail(ctx context.Context, viewer *entity.Profile, profileID uint64,
+	qp *entity.QueryParamsGetProfileDetail) (*entity.ResponseProfileDetail, error) {
+	p, err := uc.repo.FindById(ctx, profileID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindById by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	m, isExistMatch, err := uc.repo.FindMatchByProfiles(ctx, viewer.ID, profileID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindMatchByProfiles by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if isExistMatch && m.IsDeleted {
+		return nil, ErrProfileNotFound
+	}
+	if err := uc.refreshViewer(ctx, viewer, qp.Latitude, qp.Longitude); err != nil {
+		return nil, err
+	}
+	t, err := uc.repo.FindTelegramByProfileID(ctx, profileID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindTelegramByProfileID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	f, err := uc.repo.FindFilterByProfileID(ctx, profileID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindFilterByProfileID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	n, err := uc.repo.FindNavigatorByProfileIDAndViewerID(ctx, p.ID, viewer.ID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindNavigatorByProfileIDAndViewerID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	i, err := uc.repo.SelectListPublicImage(ctx, profileID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method SelectListPublicImage by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	l, isExistLike, err := uc.repo.FindLikeByLikedUserID(ctx, viewer.ID, profileID)
+	if err != nil {
+		uc.logger.Debug("error func GetProfileDetail, method FindLikeByLikedUserID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	response := &entity.ResponseProfileDetail{
+		ID:             p.ID,
+		SessionID:      p.SessionID,
+		DisplayName:    p.DisplayName,
+		Birthday:       p.Birthday,
+		Gender:         p.Gender,
+		Location:       p.Location,
+		Description:    p.Description,
+		Height:         p.Height,
+		Weight:         p.Weight,
+		LookingFor:     p.LookingFor,
+		IsDeleted:      p.IsDeleted,
+		IsBlocked:      p.IsBlocked,
+		IsPremium:      p.IsPremium,
+		IsShowDistance: p.IsShowDistance,
+		IsInvisible:    p.IsInvisible,
+		IsOnline:       time.Since(p.LastOnline).Minutes() < 5,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+		LastOnline:     p.LastOnline,
+		Images:         i,
+		Telegram:       t,
+		Navigator:      n,
+		Filter:         f,
+	}
+	if isExistLike {
+		response.Like = &entity.ResponseLikeProfile{
+			ID:        &l.ID,
+			IsLiked:   l.IsLiked,
+			UpdatedAt: &l.UpdatedAt,
+		}
+	}
+	return response, nil
+}
+
+// GetOwnProfile returns the profile of the viewer with its telegram account, filter and image. The session in the
+// path only has to agree with the signed init data. The location, when given, becomes the location of the viewer.
+func (uc *ProfileUseCases) GetOwnProfile(ctx context.Context, viewer *entity.Profile, sessionID string,
+	qp *entity.QueryParamsGetProfileByUserID) (*entity.ResponseProfile, error) {
+	if sessionID != viewer.SessionID {
+		return nil, ErrForbidden
+	}
+	if err := uc.refreshViewer(ctx, viewer, qp.Latitude, qp.Longitude); err != nil {
+		return nil, err
+	}
+	t, err := uc.FindTelegramByProfileID(ctx, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := uc.FindFilterByProfileID(ctx, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	i, err := uc.SelectListPublicImage(ctx, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := &entity.ResponseProfile{
+		ID:        viewer.ID,
+		SessionID: viewer.SessionID,
+		IsDeleted: viewer.IsDeleted,
+		IsBlocked: viewer.IsBlocked,
+		Telegram:  &entity.ResponseTelegramProfile{TelegramID: t.TelegramID},
+		Filter: &entity.ResponseFilterProfile{
+			ID:           f.ID,
+			SearchGender: f.SearchGender,
+			LookingFor:   f.LookingFor,
+			AgeFrom:      f.AgeFrom,
+			AgeTo:        f.AgeTo,
+			Distance:     f.Distance,
+			Page:         f.Page,
+			Size:         f.Size,
+			HeightFrom:   f.HeightFrom,
+			HeightTo:     f.HeightTo,
+			WeightFrom:   f.WeightFrom,
+			WeightTo:     f.WeightTo,
+		},
+	}
+	if len(i) > 0 {
+		response.Image = &entity.ResponseImageProfile{
+			Url: i[0].Url,
+		}
+	}
+	return response, nil
+}
+
+// GetMatchDetail returns the profile the viewer has the match with, the distance is left out unless both
+// locations are known
+func (uc *ProfileUseCases) GetMatchDetail(
+	ctx context.Context, viewer *entity.Profile, matchID uint64) (*entity.ResponseMatchDetail, error) {
+	m, err := uc.findMatchForViewer(ctx, viewer, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	matchedUserID := m.MatchedUserID
+	if m.MatchedUserID == viewer.ID {
+		matchedUserID = m.ProfileID
+	}
+	p, err := uc.findAvailableProfile(ctx, matchedUserID)
+	if errors.Is(err, ErrProfileUnavailable) {
+		return nil, ErrMatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := uc.FindTelegramByProfileID(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := uc.FindNavigatorByProfileIDAndViewerID(ctx, p.ID, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	i, err := uc.SelectListPublicImage(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.ResponseMatchDetail{
+		ID:          m.ID,
+		ProfileID:   p.ID,
+		DisplayName: p.DisplayName,
+		Birthday:    p.Birthday,
+		Description: p.Description,
+		IsOnline:    time.Since(p.LastOnline).Minutes() < 5,
+		LastOnline:  p.LastOnline,
+		Images:      i,
+		Telegram:    &entity.ResponseTelegramProfile{TelegramID: t.TelegramID},
+		Navigator:   n,
+		CreatedAt:   m.CreatedAt,
+	}, nil
+}
+
+// RemoveMatch deletes the match of the viewer, the profiles of the match no longer see each other
+func (uc *ProfileUseCases) RemoveMatch(
+	ctx context.Context, viewer *entity.Profile, matchID uint64) (*entity.MatchProfile, error) {
+	m, err := uc.findMatchForViewer(ctx, viewer, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	matchDto := &entity.MatchProfile{
+		ID:            m.ID,
+		ProfileID:     m.ProfileID,
+		MatchedUserID: m.MatchedUserID,
+		IsDeleted:     true,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     time.Now().UTC(),
+	}
+	return uc.DeleteMatch(ctx, matchDto)
+}
+
+// SelectDeck returns the next profiles to swipe by the saved filter of the viewer. The liked, matched and blocked
+// profiles never come back, the passed ones come back after the pass cooldown.
+func (uc *ProfileUseCases) SelectDeck(
+	ctx context.Context, viewer *entity.Profile, size uint64) (*entity.ResponseDeck, error) {
+	f, err := uc.repo.FindFilterByProfileID(ctx, viewer.ID)
+	if err != nil {
+		uc.logger.Debug("error func SelectDeck, method FindFilterByProfileID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if size == 0 {
+		size = defaultDeckSize
+	}
+	qp := &entity.QueryParamsProfileList{
+		Pagination:   entity.Pagination{Size: size},
+		SessionID:    viewer.SessionID,
+		AgeFrom:      f.AgeFrom,
+		AgeTo:        f.AgeTo,
+		SearchGender: f.SearchGender,
+		LookingFor:   f.LookingFor,
+		Distance:     f.Distance,
+		HeightFrom:   f.HeightFrom,
+		HeightTo:     f.HeightTo,
+		WeightFrom:   f.WeightFrom,
+		WeightTo:     f.WeightTo,
+	}
+	// the zero time keeps every pass, so the passed profiles never come back without a cooldown
+	var passedAfter time.Time
+	if uc.passCooldown > 0 {
+		passedAfter = time.Now().UTC().Add(-uc.passCooldown)
+	}
+	response, err := uc.repo.SelectDeck(ctx, viewer, qp, passedAfter)
+	if err != nil {
+		uc.logger.Debug("error func SelectDeck, method SelectDeck by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// PassProfile hides the profile from the deck of the viewer for the pass cooldown
+func (uc *ProfileUseCases) PassProfile(
+	ctx context.Context, viewer *entity.Profile, passedUserID uint64) (*entity.PassProfile, error) {
+	if viewer.ID == passedUserID {
+		return nil, ErrSelfAction
+	}
+	if _, err := uc.findAvailableProfile(ctx, passedUserID); err != nil {
+		return nil, err
+	}
+	passDto := &entity.PassProfile{
+		ProfileID:    viewer.ID,
+		PassedUserID: passedUserID,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	response, err := uc.repo.AddPass(ctx, passDto)
+	if err != nil {
+		uc.logger.Debug("error func PassProfile, method AddPass by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// LikeProfile likes the profile on behalf of the viewer. A mutual like creates a match. The like, the match and
+// their notifications are stored together or not at all, a repeated like doesn't notify anybody again.
+func (uc *ProfileUseCases) LikeProfile(
+	ctx context.Context, viewer *entity.Profile, likedUserID uint64, message string) (*entity.ResponseAddLike, error) {
+	if viewer.ID == likedUserID {
+		return nil, ErrSelfAction
+	}
+	if _, err := uc.findAvailableProfile(ctx, likedUserID); err != nil {
+		return nil, err
+	}
+	m, isExistMatch, err := uc.repo.FindMatchByProfiles(ctx, viewer.ID, likedUserID)
+	if err != nil {
+		uc.logger.Debug("error func LikeProfile, method FindMatchByProfiles by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if isExistMatch && m.IsDeleted {
+		return nil, ErrProfileNotFound
+	}
+	if err := uc.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	likeInDB, isExistLike, err := uc.repo.FindLikeByLikedUserID(ctx, viewer.ID, likedUserID)
+	if err != nil {
+		uc.logger.Debug("error func LikeProfile, method FindLikeByLikedUserID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	isAlreadyLiked := isExistLike && likeInDB.IsLiked
+	viewerTelegram, err := uc.FindTelegramByProfileID(ctx, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	likedTelegram, err := uc.FindTelegramByProfileID(ctx, likedUserID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(message) == "" {
+		message = defaultLikeMessage
+	}
+	response := &entity.ResponseAddLike{
+		IsMatch: false,
+		Match:   nil,
+	}
+	events := make([]*entity.Event, 0, 2)
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		likeDto := &entity.LikeProfile{
+			ProfileID:   viewer.ID,
+			LikedUserID: likedUserID,
+			IsLiked:     true,
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+		}
+		like, err := uc.repo.AddLike(ctx, likeDto)
+		if err != nil {
+			return err
+		}
+		response.LikeProfile = like
+		reverseLike, isExistReverseLike, err := uc.repo.FindLikeByLikedUserID(ctx, likedUserID, viewer.ID)
+		if err != nil {
+			return err
+		}
+		if isExistReverseLike && reverseLike.IsLiked {
+			matchDto := &entity.MatchProfile{
+				ProfileID:     viewer.ID,
+				MatchedUserID: likedUserID,
+				CreatedAt:     time.Now().UTC(),
+				UpdatedAt:     time.Now().UTC(),
+			}
+			match, isCreated, err := uc.repo.AddMatch(ctx, matchDto)
+			if err != nil {
+				return err
+			}
+			response.IsMatch = true
+			response.Match = match
+			if isCreated {
+				events = append(events, &entity.Event{
+					Type:      entity.EventTypeMatch,
+					ProfileID: likedUserID,
+					Payload:   match,
+				}, &entity.Event{
+					Type:      entity.EventTypeMatch,
+					ProfileID: viewer.ID,
+					Payload:   match,
+				})
+				err := uc.Outbox.AddNotification(ctx, likedUserID, &entity.Content{
+					ChatID:   likedTelegram.ChatID,
+					Type:     entity.EventTypeMatch,
+					Message:  fmt.Sprintf("У вас взаимная симпатия с @%s", viewerTelegram.UserName),
+					Username: viewerTelegram.UserName,
+				})
+				if err != nil {
+					return err
+				}
+				return uc.Outbox.AddNotification(ctx, viewer.ID, &entity.Content{
+					ChatID:   viewerTelegram.ChatID,
+					Type:     entity.EventTypeMatch,
+					Message:  fmt.Sprintf("У вас взаимная симпатия с @%s", likedTelegram.UserName),
+					Username: likedTelegram.UserName,
+				})
+			}
+		}
+		if isAlreadyLiked {
+			return nil
+		}
+		events = append(events, &entity.Event{
+			Type:      entity.EventTypeLike,
+			ProfileID: likedUserID,
+			Payload:   like,
+		})
+		return uc.Outbox.AddNotification(ctx, likedUserID, &entity.Content{
+			ChatID:   likedTelegram.ChatID,
+			Type:     entity.EventTypeLike,
+			Message:  message,
+			Username: viewerTelegram.UserName,
+		})
+	})
+	if err != nil {
+		uc.logger.Debug("error func LikeProfile, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	for _, e := range events {
+		uc.Hub.Publish(e)
+	}
+	return response, nil
+}
+
+// RenewLike likes the profile of the withdrawn like again, it detects the match and notifies like LikeProfile
+func (uc *ProfileUseCases) RenewLike(
+	ctx context.Context, pr *entity.Principal, likeID uint64) (*entity.ResponseAddLike, error) {
+	l, err := uc.findLikeForChange(ctx, pr, likeID)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := uc.findProfile(ctx, l.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.LikeProfile(ctx, viewer, l.LikedUserID, "")
+}
+
+// WithdrawLike takes the like back. The match of the profiles is deleted with it, so they can no longer chat
+// and the match is gone from their lists.
+func (uc *ProfileUseCases) WithdrawLike(
+	ctx context.Context, pr *entity.Principal, likeID uint64) (*entity.LikeProfile, error) {
+	l, err := uc.findLikeForChange(ctx, pr, likeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, l.ProfileID); err != nil {
+		return nil, err
+	}
+	likeDto := &entity.LikeProfile{
+		ID:          l.ID,
+		ProfileID:   l.ProfileID,
+		LikedUserID: l.LikedUserID,
+		IsLiked:     false,
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := uc.repo.DeleteLike(ctx, likeDto); err != nil {
+			return err
+		}
+		m, isExistMatch, err := uc.repo.FindMatchByProfiles(ctx, l.ProfileID, l.LikedUserID)
+		if err != nil {
+			return err
+		}
+		if !isExistMatch || m.IsDeleted {
+			return nil
+		}
+		matchDto := &entity.MatchProfile{
+			ID:            m.ID,
+			ProfileID:     m.ProfileID,
+			MatchedUserID: m.MatchedUserID,
+			IsDeleted:     true,
+			CreatedAt:     m.CreatedAt,
+			UpdatedAt:     time.Now().UTC(),
+		}
+		_, err = uc.repo.DeleteMatch(ctx, matchDto)
+		return err
+	})
+	if err != nil {
+		uc.logger.Debug("error func WithdrawLike, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return likeDto, nil
+}
+
+// BlockProfile blocks the profile for the viewer and the viewer for the profile, so they no longer see each other
+func (uc *ProfileUseCases) BlockProfile(
+	ctx context.Context, viewer *entity.Profile, blockedUserID uint64) (*entity.BlockedProfile, error) {
+	if viewer.ID == blockedUserID {
+		return nil, ErrSelfAction
+	}
+	if _, err := uc.findProfile(ctx, blockedUserID); err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	var block *entity.BlockedProfile
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		block, err = uc.addBlock(ctx, viewer.ID, blockedUserID)
+		if err != nil {
+			return err
+		}
+		_, err = uc.addBlock(ctx, blockedUserID, viewer.ID)
+		return err
+	})
+	if err != nil {
+		uc.logger.Debug("error func BlockProfile, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	uc.Hub.Publish(&entity.Event{
+		Type:      entity.EventTypeProfileBlocked,
+		ProfileID: blockedUserID,
+		Payload:   &entity.ProfileBlockedPayload{ProfileID: viewer.ID},
+	})
+	return block, nil
+}
+
+// UnblockProfile lifts the block, only the profile that blocked or an administrator can do it
+func (uc *ProfileUseCases) UnblockProfile(
+	ctx context.Context, pr *entity.Principal, blockID uint64) (*entity.BlockedProfile, error) {
+	b, isExist, err := uc.repo.FindBlockByID(ctx, blockID)
+	if err != nil {
+		uc.logger.Debug("error func UnblockProfile, method FindBlockByID by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	if !isExist {
+		return nil, ErrBlockNotFound
+	}
+	if err := uc.AuthorizeBlock(pr, b); err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, b.ProfileID); err != nil {
+		return nil, err
+	}
+	blockDto := &entity.BlockedProfile{
+		ID:            b.ID,
+		ProfileID:     b.ProfileID,
+		BlockedUserID: b.BlockedUserID,
+		IsBlocked:     false,
+		CreatedAt:     b.CreatedAt,
+		UpdatedAt:     time.Now().UTC(),
+	}
+	return uc.UpdateBlock(ctx, blockDto)
+}
+
+// ReportProfile stores the complaint of the viewer and hides the profile from them. The profile is blocked
+// when it collects enough complaints during the current month.
+func (uc *ProfileUseCases) ReportProfile(ctx context.Context, viewer *entity.Profile, complaintUserID uint64,
+	reason string) (*entity.ComplaintProfile, error) {
+	if viewer.ID == complaintUserID {
+		return nil, ErrSelfAction
+	}
+	if _, err := uc.findProfile(ctx, complaintUserID); err != nil {
+		return nil, err
+	}
+	if err := uc.UpdateLastOnline(ctx, viewer.ID); err != nil {
+		return nil, err
+	}
+	var complaint *entity.ComplaintProfile
+	err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		complaintDto := &entity.ComplaintProfile{
+			ProfileID:       viewer.ID,
+			ComplaintUserID: complaintUserID,
+			Reason:          reason,
+			CreatedAt:       time.Now().UTC(),
+			UpdatedAt:       time.Now().UTC(),
+		}
+		var err error
+		complaint, err = uc.repo.AddComplaint(ctx, complaintDto)
+		if err != nil {
+			return err
+		}
+		if _, err := uc.addBlock(ctx, viewer.ID, complaintUserID); err != nil {
+			return err
+		}
+		complaints, err := uc.repo.SelectListComplaintByID(ctx, complaintUserID)
+		if err != nil {
+			return err
+		}
+		if countComplaintsByCurrentMonth(complaints) < complaintsToBlock {
+			return nil
+		}
+		_, err = uc.repo.UpdateIsBlocked(ctx, complaintUserID, true)
+		return err
+	})
+	if err != nil {
+		uc.logger.Debug("error func ReportProfile, method WithinTransaction by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return complaint, nil
+}
+
+// findProfileForChange returns the profile with the given id, the principal's own profile when the id is 0.
+// It fails if the principal isn't allowed to change the profile.
+func (uc *ProfileUseCases) findProfileForChange(
+	ctx context.Context, pr *entity.Principal, profileID uint64) (*entity.Profile, error) {
+	if profileID == 0 && pr != nil {
+		profileID = pr.ProfileID
+	}
+	p, err := uc.findProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.AuthorizeProfile(pr, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// findLikeForChange returns the like if the principal may change it
+func (uc *ProfileUseCases) findLikeForChange(
+	ctx context.Context, pr *entity.Principal, likeID uint64) (*entity.LikeProfile, error) {
+	l, isExist, err := uc.FindLikeByID(ctx, likeID)
+	if err != nil {
+		return nil, err
+	}
+	if !isExist {
+		return nil, ErrLikeNotFound
+	}
+	if err := uc.AuthorizeLike(pr, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// findMatchForViewer returns the match if the viewer is one of its profiles and it hasn't been deleted
+func (uc *ProfileUseCases) findMatchForViewer(
+	ctx context.Context, viewer *entity.Profile, matchID uint64) (*entity.MatchProfile, error) {
+	m, isExist, err := uc.FindMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if !isExist {
+		return nil, ErrMatchNotFound
+	}
+	if m.ProfileID != viewer.ID && m.MatchedUserID != viewer.ID {
+		return nil, ErrForbidden
+	}
+	if m.IsDeleted {
+		return nil, ErrMatchNotFound
+	}
+	return m, nil
+}
+
+func (uc *ProfileUseCases) findProfile(ctx context.Context, profileID uint64) (*entity.Profile, error) {
+	p, err := uc.repo.FindById(ctx, profileID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		uc.logger.Debug("error func findProfile, method FindById by path"+
+			" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		return nil, err
+	}
+	return p, nil
+}
+
+// findAvailableProfile returns the profile if it is neither deleted nor blocked
+func (uc *ProfileUseCases) findAvailableProfile(ctx context.Context, profileID uint64) (*entity.Profile, error) {
+	p, err := uc.findProfile(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if p.IsDeleted || p.IsBlocked {
+		return nil, ErrProfileUnavailable
+	}
+	return p, nil
+}
+
+// loadProfile returns the profile with its public images, telegram account and filter
+func (uc *ProfileUseCases) loadProfile(ctx context.Context, profileID uint64) (*entity.Profile, error) {
+	p, err := uc.FindById(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	p.Telegram, err = uc.FindTelegramByProfileID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	p.Filter, err = uc.FindFilterByProfileID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	p.Images, err = uc.SelectListPublicImage(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (uc *ProfileUseCases) addBlock(
+	ctx context.Context, profileID uint64, blockedUserID uint64) (*entity.BlockedProfile, error) {
+	blockDto := &entity.BlockedProfile{
+		ProfileID:     profileID,
+		BlockedUserID: blockedUserID,
+		IsBlocked:     true,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	return uc.repo.AddBlock(ctx, blockDto)
+}
+
+// saveImages stores the uploaded files, the already saved ones are removed if one of them fails
+func (uc *ProfileUseCases) saveImages(
+	owner string, files []*multipart.FileHeader) ([]*entity.ImageProfile, []string, error) {
+	images := make([]*entity.ImageProfile, 0, len(files))
+	filePaths := make([]string, 0, len(files))
+	for _, file := range files {
+		image, err := uc.images.Save(owner, file)
+		if err != nil {
+			uc.logger.Debug("error func saveImages, method Save by path"+
+				" internal/usecases/profile/profile_actions.go", zap.Error(err))
+			uc.removeImages(filePaths)
+			return nil, nil, err
+		}
+		images = append(images, image)
+		filePaths = append(filePaths, imageFiles(image)...)
+	}
+	return images, filePaths, nil
+}
+
+// imageFiles are the files of the image on the disk, the images uploaded before the thumbnails have only one
+func imageFiles(i *entity.ImageProfile) []string {
+	if i.ThumbnailUrl == "" {
+		return []string{i.Url}
+	}
+	return []string{i.Url, i.ThumbnailUrl}
+}
+
+// removeImages cleans up the image files, a failure only leaves garbage on the disk
+func (uc *ProfileUseCases) removeImages(filePaths []string) {
+	for _, filePath := range filePaths {
+		if err := uc.images.Remove(filePath); err != nil {
+			uc.logger.Error("error func removeImages, method Remove by path"+
+				" internal/usecases/profile/profile_actions.go", zap.Error(err))
+		}
+	}
+}
+
+// countComplaintsByCurrentMonth возвращает кол-во жалоб за текущий месяц
+func countComplaintsByCurrentMonth(complaints []*entity.ComplaintProfile) int {
+	currentMonth := time.Now().UTC().Month()
+	currentYear := time.Now().UTC().Year()
+	count := 0
+	for _, complaint := range complaints {
+		if complaint.CreatedAt.Month() == currentMonth && complaint.CreatedAt.Year() == currentYear {
+			count++
+		}
+	}
+	return count
+}
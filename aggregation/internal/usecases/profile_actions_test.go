@@ -0,0 +1,442 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// fakeProfileRepo keeps the profiles in memory and records the writes. The embedded interface is nil, so a call
+// of a method the test does not expect panics.
+type fakeProfileRepo struct {
+	ProfileRepo
+	profiles   map[uint64]*entity.Profile
+	match      *entity.MatchProfile
+	like       *entity.LikeProfile
+	lastOnline []uint64
+	navigators []*entity.NavigatorProfile
+	filters    []*entity.FilterProfile
+	listQuery  *entity.QueryParamsProfileList
+	restored   []uint64
+	likes      []*entity.LikeProfile
+	deleted    []*entity.MatchProfile
+}
+
+func (r *fakeProfileRepo) UpdateLastOnline(_ context.Context, profileID uint64) error {
+	r.lastOnline = append(r.lastOnline, profileID)
+	return nil
+}
+
+func (r *fakeProfileRepo) UpdateNavigator(
+	_ context.Context, p *entity.NavigatorProfile) (*entity.NavigatorProfile, error) {
+	r.navigators = append(r.navigators, p)
+	return p, nil
+}
+
+func (r *fakeProfileRepo) FindFilterByProfileID(_ context.Context, profileID uint64) (*entity.FilterProfile, error) {
+	return &entity.FilterProfile{ID: profileID + 100, ProfileID: profileID}, nil
+}
+
+func (r *fakeProfileRepo) UpdateFilter(_ context.Context, p *entity.FilterProfile) (*entity.FilterProfile, error) {
+	r.filters = append(r.filters, p)
+	return p, nil
+}
+
+func (r *fakeProfileRepo) SelectList(
+	_ context.Context, qp *entity.QueryParamsProfileList) (*entity.ResponseListProfile, error) {
+	r.listQuery = qp
+	return &entity.ResponseListProfile{}, nil
+}
+
+func (r *fakeProfileRepo) FindById(_ context.Context, id uint64) (*entity.Profile, error) {
+	p, ok := r.profiles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *fakeProfileRepo) FindMatchByProfiles(
+	_ context.Context, _ uint64, _ uint64) (*entity.MatchProfile, bool, error) {
+	return r.match, r.match != nil, nil
+}
+
+func (r *fakeProfileRepo) FindTelegramByProfileID(
+	_ context.Context, profileID uint64) (*entity.TelegramProfile, error) {
+	return &entity.TelegramProfile{ProfileID: profileID, ChatID: profileID + 1000}, nil
+}
+
+func (r *fakeProfileRepo) FindNavigatorByProfileIDAndViewerID(
+	_ context.Context, _ uint64, _ uint64) (*entity.ResponseNavigatorProfile, error) {
+	return &entity.ResponseNavigatorProfile{Distance: 1500}, nil
+}
+
+func (r *fakeProfileRepo) SelectListPublicImage(_ context.Context, _ uint64) ([]*entity.ImageProfile, error) {
+	return nil, nil
+}
+
+func (r *fakeProfileRepo) FindLikeByLikedUserID(
+	_ context.Context, _ uint64, _ uint64) (*entity.LikeProfile, bool, error) {
+	return r.like, r.like != nil, nil
+}
+
+func (r *fakeProfileRepo) FindLikeByID(_ context.Context, id uint64) (*entity.LikeProfile, bool, error) {
+	if r.like == nil || r.like.ID != id {
+		return nil, false, nil
+	}
+	return r.like, true, nil
+}
+
+func (r *fakeProfileRepo) AddLike(_ context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error) {
+	r.likes = append(r.likes, p)
+	return p, nil
+}
+
+func (r *fakeProfileRepo) DeleteLike(_ context.Context, p *entity.LikeProfile) (*entity.LikeProfile, error) {
+	r.likes = append(r.likes, p)
+	return p, nil
+}
+
+func (r *fakeProfileRepo) DeleteMatch(_ context.Context, p *entity.MatchProfile) (*entity.MatchProfile, error) {
+	r.deleted = append(r.deleted, p)
+	return p, nil
+}
+
+func (r *fakeProfileRepo) FindMatchByID(_ context.Context, id uint64) (*entity.MatchProfile, bool, error) {
+	if r.match == nil || r.match.ID != id {
+		return nil, false, nil
+	}
+	return r.match, true, nil
+}
+
+func (r *fakeProfileRepo) Restore(_ context.Context, profileID uint64) (bool, error) {
+	r.restored = append(r.restored, profileID)
+	return true, nil
+}
+
+func newTestProfileUseCases(r *fakeProfileRepo) *ProfileUseCases {
+	return NewProfileUseCases(zap.NewNop(), r, nil, nil, 0, nil, nil)
+}
+
+func TestProfileUseCases_SearchProfiles(t *testing.T) {
+	latitude, longitude := 55.75, 37.62
+	tests := []struct {
+		name          string
+		cursor        string
+		latitude      *float64
+		longitude     *float64
+		wantNavigator bool
+	}{
+		{"with the location", "", &latitude, &longitude, true},
+		{"without the location", "", nil, nil, false},
+		{"with the latitude only", "", &latitude, nil, false},
+		{"next page by the cursor", "cursor", &latitude, &longitude, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeProfileRepo{}
+			uc := newTestProfileUseCases(r)
+			viewer := &entity.Profile{ID: 1, SessionID: "session"}
+			qp := &entity.QueryParamsProfileList{
+				Pagination:   entity.Pagination{Page: 2, Size: 20, Cursor: tt.cursor},
+				AgeFrom:      20,
+				AgeTo:        30,
+				SearchGender: "woman",
+				LookingFor:   "dates",
+				Distance:     50,
+				HeightFrom:   160,
+				Latitude:     tt.latitude,
+				Longitude:    tt.longitude,
+			}
+			if _, err := uc.SearchProfiles(context.Background(), viewer, qp); err != nil {
+				t.Fatalf("SearchProfiles() error = %v", err)
+			}
+			if len(r.lastOnline) != 1 || r.lastOnline[0] != viewer.ID {
+				t.Errorf("last online updated for %v, want [%d]", r.lastOnline, viewer.ID)
+			}
+			if got := len(r.navigators) == 1; got != tt.wantNavigator {
+				t.Errorf("navigator updated = %v, want %v", got, tt.wantNavigator)
+			}
+			wantPoint := entity.Point{Latitude: latitude, Longitude: longitude}
+			if tt.wantNavigator && *r.navigators[0].Location != wantPoint {
+				t.Errorf("navigator location = %+v", *r.navigators[0].Location)
+			}
+			if len(r.filters) != 1 {
+				t.Fatalf("filter updated %d times, want 1", len(r.filters))
+			}
+			f := r.filters[0]
+			want := entity.FilterProfile{
+				ID:           101,
+				ProfileID:    viewer.ID,
+				SearchGender: "woman",
+				LookingFor:   "dates",
+				AgeFrom:      20,
+				AgeTo:        30,
+				Distance:     50,
+				Page:         2,
+				Size:         20,
+				HeightFrom:   160,
+			}
+			if *f != want {
+				t.Errorf("filter = %+v, want %+v", *f, want)
+			}
+			if r.listQuery != qp || r.listQuery.SessionID != viewer.SessionID {
+				t.Errorf("SelectList got the query %+v, want the session %q", r.listQuery, viewer.SessionID)
+			}
+		})
+	}
+}
+
+func TestProfileUseCases_GetProfileDetail(t *testing.T) {
+	updatedAt := time.Date(2024, time.October, 20, 15, 42, 7, 0, time.UTC)
+	profile := &entity.Profile{ID: 2, DisplayName: "Anna", LastOnline: time.Now().UTC()}
+	tests := []struct {
+		name      string
+		profileID uint64
+		match     *entity.MatchProfile
+		like      *entity.LikeProfile
+		wantErr   error
+		wantLike  bool
+	}{
+		{"found", 2, nil, nil, nil, false},
+		{"liked", 2, nil, &entity.LikeProfile{ID: 5, IsLiked: true, UpdatedAt: updatedAt}, nil, true},
+		{"matched", 2, &entity.MatchProfile{ID: 3}, nil, nil, false},
+		{"deleted match", 2, &entity.MatchProfile{ID: 3, IsDeleted: true}, nil, ErrProfileNotFound, false},
+		{"not found", 9, nil, nil, ErrProfileNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeProfileRepo{
+				profiles: map[uint64]*entity.Profile{profile.ID: profile},
+				match:    tt.match,
+				like:     tt.like,
+			}
+			uc := newTestProfileUseCases(r)
+			viewer := &entity.Profile{ID: 1}
+			got, err := uc.GetProfileDetail(context.Background(), viewer, tt.profileID,
+				&entity.QueryParamsGetProfileDetail{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetProfileDetail() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(r.lastOnline) != 0 {
+					t.Errorf("last online updated for %v, want none", r.lastOnline)
+				}
+				return
+			}
+			if got.ID != profile.ID || got.DisplayName != profile.DisplayName || !got.IsOnline {
+				t.Errorf("GetProfileDetail() = %+v", got)
+			}
+			if got.Navigator == nil || got.Navigator.Distance != 1500 {
+				t.Errorf("navigator = %+v, want the distance 1500", got.Navigator)
+			}
+			if len(r.navigators) != 0 {
+				t.Errorf("navigator updated without the location")
+			}
+			if (got.Like != nil) != tt.wantLike {
+				t.Fatalf("like = %+v, want present %v", got.Like, tt.wantLike)
+			}
+			if tt.wantLike &&
+				(*got.Like.ID != tt.like.ID || !got.Like.IsLiked || !got.Like.UpdatedAt.Equal(updatedAt)) {
+				t.Errorf("like = %+v, want %+v", got.Like, tt.like)
+			}
+		})
+	}
+}
+
+func TestProfileUseCases_Restore(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     *entity.Profile
+		wantExist   bool
+		wantErr     error
+		wantRestore bool
+	}{
+		{"deleted", &entity.Profile{ID: 2, SessionID: "session", DisplayName: "Anna", IsDeleted: true},
+			true, nil, true},
+		{"erased", &entity.Profile{ID: 2, IsDeleted: true}, false, ErrProfileErased, false},
+		{"not deleted", &entity.Profile{ID: 2, SessionID: "session", DisplayName: "Anna"}, false, nil, false},
+		{"not found", &entity.Profile{ID: 9}, false, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeProfileRepo{profiles: map[uint64]*entity.Profile{tt.profile.ID: tt.profile}}
+			uc := newTestProfileUseCases(r)
+			isExist, err := uc.Restore(context.Background(), 2)
+			if !errors.Is(err, tt.wantErr) || isExist != tt.wantExist {
+				t.Fatalf("Restore() = %v, %v, want %v, %v", isExist, err, tt.wantExist, tt.wantErr)
+			}
+			if got := len(r.restored) == 1; got != tt.wantRestore {
+				t.Errorf("restored %v, want the restore %v", r.restored, tt.wantRestore)
+			}
+		})
+	}
+}
+
+func TestProfileUseCases_WithdrawLike(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *entity.Principal
+		likeID     uint64
+		match      *entity.MatchProfile
+		wantErr    error
+		wantDelete bool
+	}{
+		{"like", &entity.Principal{ProfileID: 1}, 5, nil, nil, false},
+		{"match", &entity.Principal{ProfileID: 1}, 5, &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2},
+			nil, true},
+		{"deleted match", &entity.Principal{ProfileID: 1}, 5, &entity.MatchProfile{ID: 3, IsDeleted: true},
+			nil, false},
+		{"admin", &entity.Principal{IsAdmin: true}, 5, nil, nil, false},
+		{"another profile", &entity.Principal{ProfileID: 2}, 5, nil, ErrForbidden, false},
+		{"not found", &entity.Principal{ProfileID: 1}, 6, nil, ErrLikeNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeProfileRepo{
+				match: tt.match,
+				like:  &entity.LikeProfile{ID: 5, ProfileID: 1, LikedUserID: 2, IsLiked: true},
+			}
+			uc := NewProfileUseCases(zap.NewNop(), r, fakeTransactor{}, nil, 0, nil, nil)
+			got, err := uc.WithdrawLike(context.Background(), tt.principal, tt.likeID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithdrawLike() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(r.likes) != 0 || len(r.deleted) != 0 {
+					t.Errorf("like or match changed on an error")
+				}
+				return
+			}
+			if got.IsLiked || len(r.likes) != 1 || r.likes[0] != got {
+				t.Errorf("WithdrawLike() = %+v, stored %v", got, r.likes)
+			}
+			if gotDelete := len(r.deleted) == 1; gotDelete != tt.wantDelete {
+				t.Fatalf("match deleted = %v, want %v", gotDelete, tt.wantDelete)
+			}
+			if tt.wantDelete && (r.deleted[0].ID != tt.match.ID || !r.deleted[0].IsDeleted) {
+				t.Errorf("deleted match = %+v", r.deleted[0])
+			}
+		})
+	}
+}
+
+func TestProfileUseCases_RenewLike(t *testing.T) {
+	r := &fakeProfileRepo{
+		profiles: map[uint64]*entity.Profile{1: {ID: 1}, 2: {ID: 2}},
+		like:     &entity.LikeProfile{ID: 5, ProfileID: 1, LikedUserID: 2, IsLiked: false},
+	}
+	or := &fakeOutboxRepo{}
+	hub := entity.NewHub()
+	events := hub.Subscribe(2)
+	uc := NewProfileUseCases(zap.NewNop(), r, fakeTransactor{}, nil, 0, NewOutboxUseCases(zap.NewNop(), or, nil), hub)
+	got, err := uc.RenewLike(context.Background(), &entity.Principal{ProfileID: 1}, 5)
+	if err != nil {
+		t.Fatalf("RenewLike() error = %v", err)
+	}
+	if !got.IsLiked || got.ProfileID != 1 || got.LikedUserID != 2 || got.IsMatch {
+		t.Errorf("RenewLike() = %+v", got)
+	}
+	if len(or.added) != 1 || or.added[0].ProfileID != 2 || or.added[0].Type != entity.EventTypeLike {
+		t.Errorf("queued %v, want the like notification of the profile 2", or.added)
+	}
+	select {
+	case e := <-events.Events:
+		if e.Type != entity.EventTypeLike {
+			t.Errorf("published %+v", e)
+		}
+	default:
+		t.Error("like event was not published")
+	}
+	if _, err := uc.RenewLike(context.Background(), &entity.Principal{ProfileID: 2}, 5); !errors.Is(err, ErrForbidden) {
+		t.Errorf("RenewLike() of another profile error = %v, want %v", err, ErrForbidden)
+	}
+}
+
+func TestProfileUseCases_GetOwnProfile(t *testing.T) {
+	latitude, longitude := 55.75, 37.62
+	r := &fakeProfileRepo{}
+	uc := newTestProfileUseCases(r)
+	viewer := &entity.Profile{ID: 1, SessionID: "session"}
+	qp := &entity.QueryParamsGetProfileByUserID{Latitude: &latitude, Longitude: &longitude}
+	if _, err := uc.GetOwnProfile(context.Background(), viewer, "another", qp); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("GetOwnProfile() of another session error = %v, want %v", err, ErrForbidden)
+	}
+	if len(r.lastOnline) != 0 || len(r.navigators) != 0 {
+		t.Fatalf("viewer updated for another session")
+	}
+	got, err := uc.GetOwnProfile(context.Background(), viewer, "session", qp)
+	if err != nil {
+		t.Fatalf("GetOwnProfile() error = %v", err)
+	}
+	if got.ID != viewer.ID || got.Telegram == nil || got.Filter == nil || got.Filter.ID != 101 {
+		t.Errorf("GetOwnProfile() = %+v", got)
+	}
+	if len(r.lastOnline) != 1 || len(r.navigators) != 1 {
+		t.Errorf("last online updated %d times and navigator %d times, want once", len(r.lastOnline),
+			len(r.navigators))
+	}
+}
+
+func TestProfileUseCases_GetMatchDetail(t *testing.T) {
+	tests := []struct {
+		name    string
+		matchID uint64
+		match   *entity.MatchProfile
+		profile *entity.Profile
+		wantErr error
+	}{
+		{"viewer liked first", 3, &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2}, &entity.Profile{ID: 2},
+			nil},
+		{"viewer matched", 3, &entity.MatchProfile{ID: 3, ProfileID: 2, MatchedUserID: 1}, &entity.Profile{ID: 2},
+			nil},
+		{"not found", 4, &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2}, &entity.Profile{ID: 2},
+			ErrMatchNotFound},
+		{"deleted", 3, &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2, IsDeleted: true},
+			&entity.Profile{ID: 2}, ErrMatchNotFound},
+		{"another match", 3, &entity.MatchProfile{ID: 3, ProfileID: 2, MatchedUserID: 5}, &entity.Profile{ID: 2},
+			ErrForbidden},
+		{"blocked profile", 3, &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2},
+			&entity.Profile{ID: 2, IsBlocked: true}, ErrMatchNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeProfileRepo{
+				profiles: map[uint64]*entity.Profile{tt.profile.ID: tt.profile},
+				match:    tt.match,
+			}
+			uc := newTestProfileUseCases(r)
+			got, err := uc.GetMatchDetail(context.Background(), &entity.Profile{ID: 1}, tt.matchID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetMatchDetail() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.ID != tt.match.ID || got.ProfileID != 2 || got.Telegram == nil || got.Navigator == nil {
+				t.Errorf("GetMatchDetail() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestProfileUseCases_RemoveMatch(t *testing.T) {
+	r := &fakeProfileRepo{match: &entity.MatchProfile{ID: 3, ProfileID: 1, MatchedUserID: 2}}
+	uc := newTestProfileUseCases(r)
+	if _, err := uc.RemoveMatch(context.Background(), &entity.Profile{ID: 5}, 3); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("RemoveMatch() of another profile error = %v, want %v", err, ErrForbidden)
+	}
+	got, err := uc.RemoveMatch(context.Background(), &entity.Profile{ID: 2}, 3)
+	if err != nil {
+		t.Fatalf("RemoveMatch() error = %v", err)
+	}
+	if !got.IsDeleted || len(r.deleted) != 1 || r.deleted[0].ID != 3 {
+		t.Errorf("RemoveMatch() = %+v, deleted %v", got, r.deleted)
+	}
+	if len(r.lastOnline) != 1 || r.lastOnline[0] != 2 {
+		t.Errorf("last online updated for %v, want [2]", r.lastOnline)
+	}
+}
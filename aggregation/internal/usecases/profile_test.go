@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
+	"testing"
+	"time"
+)
+
+func TestBlurLike(t *testing.T) {
+	lastOnline := time.Date(2024, time.October, 20, 15, 42, 7, 0, time.UTC)
+	tests := []struct {
+		name         string
+		navigator    *entity.ResponseNavigatorProfile
+		wantDistance float64
+	}{
+		{"near", &entity.ResponseNavigatorProfile{Distance: 120.4}, 5000},
+		{"on the step", &entity.ResponseNavigatorProfile{Distance: 10000}, 10000},
+		{"far", &entity.ResponseNavigatorProfile{Distance: 12345.6}, 15000},
+		{"same place", &entity.ResponseNavigatorProfile{Distance: 0}, 5000},
+		{"no location", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &entity.ContentListLike{
+				ID:         7,
+				LikeID:     11,
+				IsOnline:   true,
+				LastOnline: lastOnline,
+				Image:      &entity.ResponseImageProfile{Url: "static/7.webp"},
+				Navigator:  tt.navigator,
+			}
+			blurLike(l)
+			if l.ID != 0 || l.LikeID != 0 || l.Image != nil || !l.IsBlurred {
+				t.Errorf("blurLike() = %+v, want the id, the like id and the image hidden", l)
+			}
+			if want := time.Date(2024, time.October, 20, 0, 0, 0, 0, time.UTC); !l.LastOnline.Equal(want) {
+				t.Errorf("blurLike() lastOnline = %v, want %v", l.LastOnline, want)
+			}
+			if tt.navigator == nil {
+				if l.Navigator != nil {
+					t.Errorf("blurLike() navigator = %+v, want nil", l.Navigator)
+				}
+				return
+			}
+			if l.Navigator.Distance != tt.wantDistance {
+				t.Errorf("blurLike() distance = %v, want %v", l.Navigator.Distance, tt.wantDistance)
+			}
+		})
+	}
+}
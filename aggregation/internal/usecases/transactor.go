@@ -0,0 +1,8 @@
+package usecases
+
+import "context"
+
+// Transactor runs several repository calls atomically
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
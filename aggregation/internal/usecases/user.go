@@ -2,29 +2,50 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/entity"
 	"github.com/EvgeniyBudaev/gravity/aggregation/internal/logger"
 	"github.com/Nerzal/gocloak/v13"
 	"go.uber.org/zap"
+	"regexp"
 	"strings"
 )
 
+var (
+	ErrInvalidMobileNumber   = errors.New("mobile number must be in the international format")
+	ErrMobileNumberNotUnique = errors.New("mobile number must be unique")
+	ErrEmailNotUnique        = errors.New("email must be unique")
+	ErrEmailAlreadyVerified  = errors.New("email is already verified")
+)
+
+var e164Regexp = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
 type Identity interface {
 	CreateUser(ctx context.Context, user gocloak.User, password string, role string) (*gocloak.User, error)
 	UpdateUser(ctx context.Context, user gocloak.User) (*gocloak.User, error)
 	DeleteUser(ctx context.Context, user gocloak.User) error
 	GetUserList(ctx context.Context, query entity.QueryParamsUserList) ([]*gocloak.User, error)
+	GetUserByID(ctx context.Context, userID string) (*gocloak.User, error)
+	FindUserByEmail(ctx context.Context, email string) (*gocloak.User, error)
+	ExecuteActionsEmail(ctx context.Context, userID string, actions []string) error
+	SendVerifyEmail(ctx context.Context, userID string) error
+	LogoutAllSessions(ctx context.Context, userID string) error
 }
 
+// keycloak required action to set a new password
+const actionUpdatePassword = "UPDATE_PASSWORD"
+
 type UseCaseUser struct {
 	logger   logger.Logger
 	identity Identity
+	profiles *ProfileUseCases
 }
 
-func NewUserUseCases(l logger.Logger, i Identity) *UseCaseUser {
+func NewUserUseCases(l logger.Logger, i Identity, p *ProfileUseCases) *UseCaseUser {
 	return &UseCaseUser{
 		logger:   l,
 		identity: i,
+		profiles: p,
 	}
 }
 
@@ -33,35 +54,65 @@ func (uc *UseCaseUser) Register(ctx context.Context, request entity.RegisterRequ
 		Username:      gocloak.StringP(request.Username),
 		FirstName:     gocloak.StringP(request.FirstName),
 		LastName:      gocloak.StringP(request.LastName),
-		Email:         gocloak.StringP(request.Email),
-		EmailVerified: gocloak.BoolP(true),
+		Email:         gocloak.StringP(strings.ToLower(strings.TrimSpace(request.Email))),
+		EmailVerified: gocloak.BoolP(false),
 		Enabled:       gocloak.BoolP(true),
 		Attributes:    &map[string][]string{},
 	}
 	if strings.TrimSpace(request.MobileNumber) != "" {
-		(*user.Attributes)["mobileNumber"] = []string{request.MobileNumber}
+		mobileNumber, err := normalizeMobileNumber(request.MobileNumber)
+		if err != nil {
+			uc.logger.Debug("error func Register, method normalizeMobileNumber by path internal/usecases/user/user.go",
+				zap.Error(err))
+			return nil, err
+		}
+		(*user.Attributes)["mobileNumber"] = []string{mobileNumber}
 	}
 	response, err := uc.identity.CreateUser(ctx, user, request.Password, "customer")
 	if err != nil {
 		uc.logger.Debug("error func Register, method CreateUser by path internal/usecases/user/user.go", zap.Error(err))
 		return nil, err
 	}
+	// the user is already created, a failed email can be requested again with /user/email/verify
+	if err := uc.identity.SendVerifyEmail(ctx, *response.ID); err != nil {
+		uc.logger.Error("error func Register, method SendVerifyEmail by path internal/usecases/user/user.go",
+			zap.Error(err))
+	}
 	return response, nil
 }
 
 func (uc *UseCaseUser) UpdateUser(ctx context.Context, request entity.RequestUpdateUser) (*gocloak.User, error) {
 	var user = gocloak.User{
-		ID:            request.ID,
-		Username:      gocloak.StringP(request.Username),
-		FirstName:     gocloak.StringP(request.FirstName),
-		LastName:      gocloak.StringP(request.LastName),
-		Email:         gocloak.StringP(request.Email),
-		EmailVerified: gocloak.BoolP(true),
-		Enabled:       gocloak.BoolP(true),
-		Attributes:    &map[string][]string{},
+		ID:         request.ID,
+		Username:   gocloak.StringP(request.Username),
+		FirstName:  gocloak.StringP(request.FirstName),
+		LastName:   gocloak.StringP(request.LastName),
+		Email:      gocloak.StringP(strings.ToLower(strings.TrimSpace(request.Email))),
+		Enabled:    gocloak.BoolP(true),
+		Attributes: &map[string][]string{},
 	}
 	if strings.TrimSpace(request.MobileNumber) != "" {
-		(*user.Attributes)["mobileNumber"] = []string{request.MobileNumber}
+		mobileNumber, err := normalizeMobileNumber(request.MobileNumber)
+		if err != nil {
+			uc.logger.Debug("error func UpdateUser, method normalizeMobileNumber by path internal/usecases/user/user.go",
+				zap.Error(err))
+			return nil, err
+		}
+		(*user.Attributes)["mobileNumber"] = []string{mobileNumber}
+	}
+	if request.ID == nil {
+		return nil, errors.New("user ID is nil")
+	}
+	current, err := uc.identity.GetUserByID(ctx, *request.ID)
+	if err != nil {
+		uc.logger.Debug("error func UpdateUser, method GetUserByID by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return nil, err
+	}
+	// a changed email has to be verified again
+	emailChanged := current.Email == nil || *current.Email != *user.Email
+	if emailChanged {
+		user.EmailVerified = gocloak.BoolP(false)
 	}
 	response, err := uc.identity.UpdateUser(ctx, user)
 	if err != nil {
@@ -69,14 +120,37 @@ func (uc *UseCaseUser) UpdateUser(ctx context.Context, request entity.RequestUpd
 			zap.Error(err))
 		return nil, err
 	}
+	if emailChanged && *user.Email != "" {
+		if err := uc.identity.SendVerifyEmail(ctx, *request.ID); err != nil {
+			uc.logger.Error("error func UpdateUser, method SendVerifyEmail by path internal/usecases/user/user.go",
+				zap.Error(err))
+		}
+	}
 	return response, nil
 }
 
+// DeleteUser soft-deletes the linked profile first, so a failure leaves the keycloak user to retry with
 func (uc *UseCaseUser) DeleteUser(ctx context.Context, request entity.RequestDeleteUser) error {
+	if request.ID == nil {
+		return errors.New("user ID is nil")
+	}
+	profile, err := uc.profiles.FindByKeycloakUserID(ctx, *request.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		uc.logger.Debug("error func DeleteUser, method FindByKeycloakUserID by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return err
+	}
+	if profile != nil && !profile.IsDeleted {
+		if _, err := uc.profiles.SoftDelete(ctx, profile); err != nil {
+			uc.logger.Debug("error func DeleteUser, method SoftDelete by path internal/usecases/user/user.go",
+				zap.Error(err))
+			return err
+		}
+	}
 	var user = gocloak.User{
 		ID: request.ID,
 	}
-	err := uc.identity.DeleteUser(ctx, user)
+	err = uc.identity.DeleteUser(ctx, user)
 	if err != nil {
 		uc.logger.Debug("error func DeleteUser, method DeleteUser by path internal/usecases/user/user.go",
 			zap.Error(err))
@@ -94,3 +168,134 @@ func (uc *UseCaseUser) GetUserList(ctx context.Context, query entity.QueryParams
 	}
 	return response, nil
 }
+
+// GetMe returns the keycloak user together with the gravity profile, the profile is nil until it is created
+func (uc *UseCaseUser) GetMe(ctx context.Context, userID string) (*entity.ResponseUserMe, error) {
+	user, err := uc.identity.GetUserByID(ctx, userID)
+	if err != nil {
+		uc.logger.Debug("error func GetMe, method GetUserByID by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return nil, err
+	}
+	profile, err := uc.profiles.FindByKeycloakUserID(ctx, userID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		uc.logger.Debug("error func GetMe, method FindByKeycloakUserID by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return nil, err
+	}
+	return &entity.ResponseUserMe{User: user, Profile: profile}, nil
+}
+
+// LinkProfile binds the keycloak user to the profile of the telegram user
+func (uc *UseCaseUser) LinkProfile(ctx context.Context, userID string, telegramID uint64) (*entity.Profile, error) {
+	profile, err := uc.profiles.FindByTelegramId(ctx, telegramID)
+	if err != nil {
+		uc.logger.Debug("error func LinkProfile, method FindByTelegramId by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return nil, err
+	}
+	if err := uc.profiles.LinkKeycloakUser(ctx, profile.ID, userID); err != nil {
+		uc.logger.Debug("error func LinkProfile, method LinkKeycloakUser by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ChangePassword sends the user an email with a link to set a new password
+func (uc *UseCaseUser) ChangePassword(ctx context.Context, userID string) error {
+	err := uc.identity.ExecuteActionsEmail(ctx, userID, []string{actionUpdatePassword})
+	if err != nil {
+		uc.logger.Debug("error func ChangePassword, method ExecuteActionsEmail by path"+
+			" internal/usecases/user/user.go", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sends the reset link if the email is registered. An unknown email isn't an error,
+// so the response doesn't reveal which emails exist.
+func (uc *UseCaseUser) ResetPassword(ctx context.Context, request entity.RequestResetPassword) error {
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if email == "" {
+		return errors.New("email is required")
+	}
+	user, err := uc.identity.FindUserByEmail(ctx, email)
+	if err != nil {
+		uc.logger.Debug("error func ResetPassword, method FindUserByEmail by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return err
+	}
+	if user == nil || user.ID == nil {
+		return nil
+	}
+	return uc.ChangePassword(ctx, *user.ID)
+}
+
+func (uc *UseCaseUser) SendVerifyEmail(ctx context.Context, userID string) error {
+	user, err := uc.identity.GetUserByID(ctx, userID)
+	if err != nil {
+		uc.logger.Debug("error func SendVerifyEmail, method GetUserByID by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return err
+	}
+	if user.EmailVerified != nil && *user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	if err := uc.identity.SendVerifyEmail(ctx, userID); err != nil {
+		uc.logger.Debug("error func SendVerifyEmail, method SendVerifyEmail by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (uc *UseCaseUser) LogoutAll(ctx context.Context, userID string) error {
+	if err := uc.identity.LogoutAllSessions(ctx, userID); err != nil {
+		uc.logger.Debug("error func LogoutAll, method LogoutAllSessions by path internal/usecases/user/user.go",
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// normalizeMobileNumber brings the number to E.164: separators are removed, the 00 prefix is replaced with +,
+// and the russian trunk prefix 8 of an 11-digit number is replaced with +7
+func normalizeMobileNumber(mobileNumber string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(mobileNumber) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", ErrInvalidMobileNumber
+		}
+	}
+	normalized := b.String()
+	switch {
+	case strings.HasPrefix(normalized, "00"):
+		normalized = "+" + normalized[2:]
+	case len(normalized) == 11 && strings.HasPrefix(normalized, "8"):
+		normalized = "+7" + normalized[1:]
+	case !strings.HasPrefix(normalized, "+"):
+		normalized = "+" + normalized
+	}
+	if !e164Regexp.MatchString(normalized) {
+		return "", ErrInvalidMobileNumber
+	}
+	return normalized, nil
+}
+
+// legacyMobileNumbers returns the forms the E.164 number could be stored in before the numbers were normalized:
+// without the plus, with the 00 prefix and, for a russian number, with the trunk prefix 8
+func legacyMobileNumbers(normalized string) []string {
+	digits := strings.TrimPrefix(normalized, "+")
+	forms := []string{digits, "00" + digits}
+	if len(digits) == 11 && strings.HasPrefix(digits, "7") {
+		forms = append(forms, "8"+digits[1:])
+	}
+	return forms
+}
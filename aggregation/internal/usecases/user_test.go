@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeMobileNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		mobileNumber string
+		want         string
+		wantErr      error
+	}{
+		{"e164", "+79123456789", "+79123456789", nil},
+		{"separators", "+7 (912) 345-67-89", "+79123456789", nil},
+		{"trunk prefix", "8 912 345 67 89", "+79123456789", nil},
+		{"00 prefix", "0049 30 1234567", "+49301234567", nil},
+		{"without plus", "79123456789", "+79123456789", nil},
+		{"letters", "+7912abc", "", ErrInvalidMobileNumber},
+		{"plus inside", "7+9123456789", "", ErrInvalidMobileNumber},
+		{"too short", "12345", "", ErrInvalidMobileNumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeMobileNumber(tt.mobileNumber)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("normalizeMobileNumber(%q) = %q, %v, want %q, %v", tt.mobileNumber, got, err, tt.want,
+					tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLegacyMobileNumbers(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalized string
+		want       []string
+	}{
+		{"russian", "+79123456789", []string{"79123456789", "0079123456789", "89123456789"}},
+		{"german", "+49301234567", []string{"49301234567", "0049301234567"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := legacyMobileNumbers(tt.normalized)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("legacyMobileNumbers(%q) = %v, want %v", tt.normalized, got, tt.want)
+			}
+			// every legacy form is normalized back to the number
+			for _, form := range got {
+				if n, err := normalizeMobileNumber(form); err != nil || n != tt.normalized {
+					t.Errorf("normalizeMobileNumber(%q) = %q, %v, want %q", form, n, err, tt.normalized)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_matches;
+DROP INDEX IF EXISTS uq_profile_likes_profile_id_liked_user_id;
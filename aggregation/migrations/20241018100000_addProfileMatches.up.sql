@@ -0,0 +1,22 @@
+DELETE FROM profile_likes a
+    USING profile_likes b
+WHERE a.profile_id = b.profile_id
+  AND a.likedUser_id = b.likedUser_id
+  AND a.id < b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_profile_likes_profile_id_liked_user_id
+    ON profile_likes (profile_id, likedUser_id);
+
+CREATE TABLE IF NOT EXISTS profile_matches (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     profile_id BIGINT NOT NULL,
+     matched_user_id BIGINT NOT NULL,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NULL CHECK (updated_at >= created_at),
+     CONSTRAINT fk_profile_matches_profile_id FOREIGN KEY (profile_id) REFERENCES profiles (id),
+     CONSTRAINT fk_profile_matches_matched_user_id FOREIGN KEY (matched_user_id) REFERENCES profiles (id),
+     CONSTRAINT chk_profile_matches_pair_order CHECK (profile_id < matched_user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_profile_matches_profile_id_matched_user_id
+    ON profile_matches (profile_id, matched_user_id);
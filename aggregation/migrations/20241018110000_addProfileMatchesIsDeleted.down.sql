@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_profile_matches_matched_user_id;
+
+ALTER TABLE profile_matches DROP COLUMN IF EXISTS is_deleted;
@@ -0,0 +1,3 @@
+ALTER TABLE profile_matches ADD COLUMN IF NOT EXISTS is_deleted BOOL NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_profile_matches_matched_user_id ON profile_matches (matched_user_id);
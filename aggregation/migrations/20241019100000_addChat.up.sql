@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS conversations (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     profile_id BIGINT NOT NULL,
+     participant_id BIGINT NOT NULL,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NULL CHECK (updated_at >= created_at),
+     CONSTRAINT fk_conversations_profile_id FOREIGN KEY (profile_id) REFERENCES profiles (id),
+     CONSTRAINT fk_conversations_participant_id FOREIGN KEY (participant_id) REFERENCES profiles (id),
+     CONSTRAINT chk_conversations_pair_order CHECK (profile_id < participant_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_conversations_profile_id_participant_id
+    ON conversations (profile_id, participant_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_participant_id ON conversations (participant_id);
+
+CREATE TABLE IF NOT EXISTS messages (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     conversation_id BIGINT NOT NULL,
+     sender_id BIGINT NOT NULL,
+     receiver_id BIGINT NOT NULL,
+     message TEXT NOT NULL,
+     is_edited BOOL NOT NULL DEFAULT false,
+     is_deleted BOOL NOT NULL DEFAULT false,
+     read_at TIMESTAMP NULL,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NULL CHECK (updated_at >= created_at),
+     CONSTRAINT fk_messages_conversation_id FOREIGN KEY (conversation_id) REFERENCES conversations (id),
+     CONSTRAINT fk_messages_sender_id FOREIGN KEY (sender_id) REFERENCES profiles (id),
+     CONSTRAINT fk_messages_receiver_id FOREIGN KEY (receiver_id) REFERENCES profiles (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id_id ON messages (conversation_id, id);
+CREATE INDEX IF NOT EXISTS idx_messages_unread
+    ON messages (conversation_id, receiver_id) WHERE read_at IS NULL AND is_deleted = false;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS notification_outbox (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     profile_id BIGINT NOT NULL,
+     type VARCHAR(50) NOT NULL,
+     payload JSONB NOT NULL,
+     status VARCHAR(20) NOT NULL DEFAULT 'pending',
+     attempts INTEGER NOT NULL DEFAULT 0,
+     last_error TEXT NULL,
+     next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     sent_at TIMESTAMP NULL,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NULL CHECK (updated_at >= created_at),
+     CONSTRAINT fk_notification_outbox_profile_id FOREIGN KEY (profile_id) REFERENCES profiles (id),
+     CONSTRAINT chk_notification_outbox_status CHECK (status IN ('pending', 'sent', 'dead'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_pending
+    ON notification_outbox (next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_status ON notification_outbox (status, id);
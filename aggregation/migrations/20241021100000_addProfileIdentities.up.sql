@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS profile_identities (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     profile_id BIGINT NOT NULL UNIQUE,
+     keycloak_user_id VARCHAR(255) NULL UNIQUE,
+     telegram_id BIGINT NULL UNIQUE,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NULL CHECK (updated_at >= created_at),
+     CONSTRAINT fk_profile_identities_profile_id FOREIGN KEY (profile_id) REFERENCES profiles (id)
+);
+
+INSERT INTO profile_identities (profile_id, telegram_id)
+SELECT DISTINCT ON (pt.telegram_id) pt.profile_id, pt.telegram_id
+FROM profile_telegram pt
+         JOIN profiles p ON p.id = pt.profile_id
+WHERE pt.telegram_id IS NOT NULL AND pt.telegram_id <> 0 AND p.is_deleted = false
+ORDER BY pt.telegram_id, pt.profile_id DESC
+ON CONFLICT DO NOTHING;
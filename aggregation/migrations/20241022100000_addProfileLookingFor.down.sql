@@ -0,0 +1,7 @@
+ALTER TABLE profile_filters
+    DROP COLUMN IF EXISTS height_from,
+    DROP COLUMN IF EXISTS height_to,
+    DROP COLUMN IF EXISTS weight_from,
+    DROP COLUMN IF EXISTS weight_to;
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS looking_for;
@@ -0,0 +1,13 @@
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS looking_for VARCHAR(100) NOT NULL DEFAULT 'all';
+
+-- the intent was only kept in the filter so far
+UPDATE profiles p
+SET looking_for = pf.looking_for
+FROM profile_filters pf
+WHERE pf.profile_id = p.id AND pf.looking_for IS NOT NULL AND pf.looking_for <> '';
+
+ALTER TABLE profile_filters
+    ADD COLUMN IF NOT EXISTS height_from INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS height_to INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS weight_from INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS weight_to INTEGER NOT NULL DEFAULT 0;
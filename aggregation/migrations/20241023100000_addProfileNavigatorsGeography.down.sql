@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_profile_filters_profile_id;
+DROP INDEX IF EXISTS idx_profile_navigators_profile_id;
+DROP INDEX IF EXISTS idx_profile_navigators_geog;
+
+ALTER TABLE profile_navigators DROP COLUMN IF EXISTS geog;
@@ -0,0 +1,6 @@
+ALTER TABLE profile_navigators
+    ADD COLUMN IF NOT EXISTS geog geography(Point, 4326) GENERATED ALWAYS AS (location::geography) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_profile_navigators_geog ON profile_navigators USING GIST (geog);
+CREATE INDEX IF NOT EXISTS idx_profile_navigators_profile_id ON profile_navigators (profile_id);
+CREATE INDEX IF NOT EXISTS idx_profile_filters_profile_id ON profile_filters (profile_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_profile_images_profile_id;
+DROP INDEX IF EXISTS idx_profile_images_primary;
+
+ALTER TABLE profile_images DROP COLUMN IF EXISTS thumbnail_url;
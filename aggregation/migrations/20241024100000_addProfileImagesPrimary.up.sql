@@ -0,0 +1,19 @@
+ALTER TABLE profile_images ADD COLUMN IF NOT EXISTS thumbnail_url TEXT NOT NULL DEFAULT '';
+
+-- every profile with the visible images gets the primary one, the oldest image is taken
+UPDATE profile_images pi SET is_primary = false
+WHERE pi.is_primary = true AND pi.is_deleted = false AND pi.id <> (
+    SELECT MIN(id) FROM profile_images
+    WHERE profile_id = pi.profile_id AND is_primary = true AND is_deleted = false);
+UPDATE profile_images pi SET is_primary = true
+WHERE pi.id IN (
+    SELECT MIN(id) FROM profile_images
+    WHERE is_deleted = false AND is_blocked = false AND is_private = false
+    GROUP BY profile_id)
+  AND NOT EXISTS (
+    SELECT 1 FROM profile_images
+    WHERE profile_id = pi.profile_id AND is_primary = true AND is_deleted = false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_images_primary
+    ON profile_images (profile_id) WHERE is_primary = true AND is_deleted = false;
+CREATE INDEX IF NOT EXISTS idx_profile_images_profile_id ON profile_images (profile_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS profile_passes (
+     id BIGSERIAL NOT NULL PRIMARY KEY,
+     profile_id BIGINT NOT NULL,
+     passed_user_id BIGINT NOT NULL,
+     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP CHECK (updated_at >= created_at),
+     CONSTRAINT fk_profile_passes_profile_id FOREIGN KEY (profile_id) REFERENCES profiles (id),
+     CONSTRAINT fk_profile_passes_passed_user_id FOREIGN KEY (passed_user_id) REFERENCES profiles (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_profile_passes_profile_id_passed_user_id
+    ON profile_passes (profile_id, passed_user_id);
@@ -1,8 +1,17 @@
 import { Environment } from "@/app/environment";
+import { INIT_DATA_COOKIE } from "@/app/shared/constants/telegram";
 import { createApi } from "@/app/shared/utils";
 
 export const { fetchApi, setApiLanguage, getApiLanguage } = createApi({
   basePath: Environment.NEXT_PUBLIC_API_URL,
   timeout: 50_000,
   retry: 1,
+  getInitData: async () => {
+    if (typeof window !== "undefined") {
+      return window.Telegram?.WebApp?.initData;
+    }
+    // запросы из server actions, данные запуска сохраняет useTelegram
+    const { cookies } = await import("next/headers");
+    return cookies().get(INIT_DATA_COOKIE)?.value;
+  },
 });
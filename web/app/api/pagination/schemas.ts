@@ -7,4 +7,5 @@ export const paginationSchema = z.object({
   size: z.number(),
   page: z.number(),
   totalItems: z.number(),
+  nextCursor: z.string().optional(),
 });
@@ -3,6 +3,7 @@ import { paginationSchema } from "@/app/api/pagination/schemas";
 
 const imageListItemSchema = z.object({
   url: z.string(),
+  thumbnailUrl: z.string().optional(),
 });
 
 const profileListItemSchema = z.object({
@@ -52,7 +52,7 @@ export const MainPage: FC<TProps> = ({ lng, profile, profileList }) => {
                 fill={true}
                 priority={true}
                 sizes="100vw"
-                src={`${proxyUrl}${item.image.url}`}
+                src={`${proxyUrl}${item.image.thumbnailUrl ?? item.image.url}`}
                 quality={100}
               />
             </div>
@@ -0,0 +1,2 @@
+// Подписанные данные запуска мини-приложения, сервер Next берет их из cookie для заголовка Authorization
+export const INIT_DATA_COOKIE = "initData";
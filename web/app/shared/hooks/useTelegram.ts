@@ -2,6 +2,7 @@
 
 import { Telegram, WebApp } from "@twa-dev/types";
 import { useEffect, useState } from "react";
+import { INIT_DATA_COOKIE } from "@/app/shared/constants/telegram";
 
 declare global {
   interface Window {
@@ -21,6 +22,10 @@ export const useTelegram = () => {
 
   useEffect(() => {
     setTg(telegram);
+    if (telegram?.initData) {
+      // мини-приложение открыто во фрейме telegram, поэтому SameSite=None
+      document.cookie = `${INIT_DATA_COOKIE}=${encodeURIComponent(telegram.initData)}; path=/; SameSite=None; Secure`;
+    }
   }, [telegram]);
 
   return {
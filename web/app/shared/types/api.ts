@@ -4,6 +4,7 @@ export type TApiConfig = {
   basePath: string;
   timeout: number;
   retry: number;
+  getInitData?: () => Promise<string | undefined>;
 };
 
 export type TApiOptions = Omit<RequestInit, "body"> & {
@@ -27,7 +27,7 @@ export function createApi(config: TApiConfig): {
   const { basePath } = config;
 
   const fetchApi: TApiFunction = async (path, options) => {
-    // const accessToken = await getAccessToken();
+    const initData = await config.getInitData?.();
     const url = basePath + path;
     let contentType: { "Content-Type"?: string } = {
       "Content-Type": "application/json",
@@ -47,7 +47,7 @@ export function createApi(config: TApiConfig): {
       ...options,
       headers: {
         ...contentType,
-        // Authorization: `Bearer ${accessToken}`,
+        ...(initData && { Authorization: `tma ${initData}` }),
         "Accept-Language": language,
         ...options?.headers,
       },